@@ -11,34 +11,22 @@ import (
 )
 
 func main() {
-	l := logger.NewLoggerMultiHandler(logger.Handler{
-		Type:   logger.LoggerTypeText,
-		Writer: os.Stdout,
-		Opts: &slog.HandlerOptions{
-			AddSource: true,
-			Level:     slog.LevelDebug,
-		},
-	})
 	t1 := time.Now()
 
 	fmt.Println("\n Dracula")
-	log(l, t1)
+	log(newLogger(&logger.Dracula), t1)
 
 	fmt.Println("\n GruvBox Dark")
-	logger.Colors = logger.GruvboxDark
-	log(l, t1)
+	log(newLogger(&logger.GruvboxDark), t1)
 
 	fmt.Println("\n One Dark")
-	logger.Colors = logger.OneDark
-	log(l, t1)
+	log(newLogger(&logger.OneDark), t1)
 
 	fmt.Println("\n Nord")
-	logger.Colors = logger.Nord
-	log(l, t1)
+	log(newLogger(&logger.Nord), t1)
 
 	fmt.Println("\n Solarized Dark")
-	logger.Colors = logger.SolarizedDark
-	log(l, t1)
+	log(newLogger(&logger.SolarizedDark), t1)
 
 	// Disable colors using NO_COLOR environment variable
 	os.Setenv("NO_COLOR", "1")
@@ -50,6 +38,21 @@ func main() {
 	slog.Info("slog-simple without colors", slog.String("log_type", "Internal"), slog.String("NO_COLOR", os.Getenv("NO_COLOR")))
 }
 
+// newLogger creates a text logger using the provided palette.
+func newLogger(palette *logger.ColorPalette) *slog.Logger {
+	return logger.NewLoggerMultiHandler(logger.Handler{
+		Type:   logger.LoggerTypeText,
+		Writer: os.Stdout,
+		Opts: &slog.HandlerOptions{
+			AddSource: true,
+			Level:     slog.LevelDebug,
+		},
+		TextOpts: &logger.TextOptions{
+			Palette: palette,
+		},
+	})
+}
+
 func log(l *slog.Logger, startTime time.Time) {
 	l.Debug("",
 		slog.String("log_type", "http_request"),
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+
+	logger "github.com/tmstorm/slog-human"
+	logmiddleware "github.com/tmstorm/slog-human/middleware/echo"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func main() {
+	l := logger.NewDefaultLogger()
+	slog.SetDefault(l)
+
+	e := echo.New()
+	e.HideBanner = true
+	// slog-human's echo middleware reads the id set by echo's
+	// RequestID middleware. If you would like the requestid to show
+	// this middleware must be in use, otherwise it will be ignored.
+	e.Use(middleware.RequestID())
+	e.Use(logmiddleware.EchoLogger(slog.Default()))
+	// The recoverer must come after the logger so panics are logged
+	// in the request's record.
+	e.Use(logmiddleware.EchoRecoverer(slog.Default()))
+
+	e.GET("/ping", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "pong",
+		})
+	})
+
+	slog.Info("Echo starting on port :3000")
+	err := e.Start(":3000")
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
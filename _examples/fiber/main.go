@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	logger "github.com/tmstorm/slog-human"
+	logmiddleware "github.com/tmstorm/slog-human/middleware/fiber"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+func main() {
+	l := logger.NewDefaultLogger()
+	slog.SetDefault(l)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	// slog-human's fiber middleware reads the id stored by fiber's
+	// requestid middleware. If you would like the requestid to show
+	// this middleware must be in use, otherwise it will be ignored.
+	app.Use(requestid.New())
+	app.Use(logmiddleware.FiberLogger(slog.Default()))
+	// The recoverer must come after the logger so panics are logged
+	// in the request's record.
+	app.Use(logmiddleware.FiberRecoverer(slog.Default()))
+
+	app.Get("/ping", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"message": "pong",
+		})
+	})
+
+	slog.Info("Fiber starting on port :3000")
+	err := app.Listen(":3000")
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
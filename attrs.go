@@ -0,0 +1,135 @@
+package sloghuman
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+type (
+	// GroupStyle is used to determine how the TextHandler renders groups
+	GroupStyle int
+
+	// field is a resolved, non-group attr along with the group path it was logged under
+	field struct {
+		groups []string
+		attr   slog.Attr
+	}
+
+	// groupBlock is a single group rendered by GroupStyleBlock
+	groupBlock struct {
+		name     string
+		fields   []string
+		children []*groupBlock
+	}
+)
+
+// Enums used to set the group style of the TextHandler
+const (
+	// GroupStyleDotted renders grouped attrs inline with their full path
+	// as the key, e.g. req.user.id=42
+	GroupStyleDotted GroupStyle = iota
+
+	// GroupStyleBlock renders grouped attrs as indented blocks beneath the
+	// log line. Attrs that are not in a group stay inline.
+	GroupStyleBlock
+)
+
+// groupIndent is the indent used for each level of a GroupStyleBlock group.
+const groupIndent = "  "
+
+// path returns the dotted key of f including all of its groups.
+func (f field) path() string {
+	if len(f.groups) == 0 {
+		return f.attr.Key
+	}
+	return strings.Join(f.groups, ".") + "." + f.attr.Key
+}
+
+// predefinedKey returns the key of f if it was logged outside of any group.
+// Only top level attrs are used for the predefined keys such as method and status.
+func (f field) predefinedKey() string {
+	if len(f.groups) != 0 {
+		return ""
+	}
+	return f.attr.Key
+}
+
+// appendAttr resolves a, runs it through ReplaceAttr and appends the result to fields.
+// Groups are flattened so every field knows its full group path, following the slog rules:
+//   - group values are not passed to ReplaceAttr, their members are with the group key appended
+//   - groups with an empty key are inlined into the current group
+//   - groups with no attrs are elided
+//   - attrs with an empty key are ignored
+func (h *TextHandler) appendAttr(fields []field, groups []string, a slog.Attr) []field {
+	a.Value = a.Value.Resolve()
+	if h.replaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.replaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			fields = h.appendAttr(fields, groups, ga)
+		}
+		return fields
+	}
+
+	if a.Key == "" {
+		return fields
+	}
+	return append(fields, field{groups: groups, attr: a})
+}
+
+// formatFields renders fields using the handlers GroupStyle. The inline string
+// is appended to the log line, block holds any lines to be printed beneath it.
+func (h *TextHandler) formatFields(fields []field) (inline string, block string) {
+	var parts []string
+	root := &groupBlock{}
+
+	for _, f := range fields {
+		if h.groupStyle != GroupStyleBlock || len(f.groups) == 0 {
+			parts = append(parts, fmt.Sprintf("%s=%v", f.path(), f.attr.Value))
+			continue
+		}
+		g := root.child(f.groups)
+		g.fields = append(g.fields, fmt.Sprintf("%s=%v", f.attr.Key, f.attr.Value))
+	}
+
+	var b strings.Builder
+	for _, g := range root.children {
+		g.write(&b, 1)
+	}
+
+	return strings.Join(parts, " "), b.String()
+}
+
+// child returns the nested block for the group path, creating any that are missing.
+func (g *groupBlock) child(groups []string) *groupBlock {
+	if len(groups) == 0 {
+		return g
+	}
+	for _, c := range g.children {
+		if c.name == groups[0] {
+			return c.child(groups[1:])
+		}
+	}
+	c := &groupBlock{name: groups[0]}
+	g.children = append(g.children, c)
+	return c.child(groups[1:])
+}
+
+// write writes the group and its children to b at the given depth.
+func (g *groupBlock) write(b *strings.Builder, depth int) {
+	indent := strings.Repeat(groupIndent, depth)
+	b.WriteString(indent + g.name + ":\n")
+	for _, f := range g.fields {
+		b.WriteString(indent + groupIndent + f + "\n")
+	}
+	for _, c := range g.children {
+		c.write(b, depth+1)
+	}
+}
@@ -0,0 +1,210 @@
+package sloghuman
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxBodySize is the number of body bytes captured when
+// MiddlewareOptions.MaxBodySize is not set.
+const DefaultMaxBodySize = 4096
+
+// RedactedValue replaces the value of redacted headers.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// DefaultRedactHeaders are the headers redacted when MiddlewareOptions.RedactHeaders is nil.
+	DefaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+	// DefaultBodyContentTypes are the content types captured when
+	// MiddlewareOptions.BodyContentTypes is nil.
+	DefaultBodyContentTypes = []string{
+		"application/json",
+		"application/*+json",
+		"application/xml",
+		"application/x-www-form-urlencoded",
+		"text/*",
+	}
+)
+
+// BodyBuffer keeps up to its limit of the bytes written to it. Writes never fail
+// so it can be used with io.TeeReader or as a tee of a response without
+// affecting the request.
+type BodyBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// NewBodyBuffer returns a BodyBuffer keeping up to limit bytes.
+func NewBodyBuffer(limit int) *BodyBuffer {
+	return &BodyBuffer{limit: limit}
+}
+
+// Write keeps as much of p as fits in the buffer and always reports success.
+func (b *BodyBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		p = p[:max(room, 0)]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// Bytes returns the captured bytes.
+func (b *BodyBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// Truncated reports whether more bytes were written than the buffer kept.
+func (b *BodyBuffer) Truncated() bool {
+	return b.truncated
+}
+
+// Len returns the number of captured bytes.
+func (b *BodyBuffer) Len() int {
+	return b.buf.Len()
+}
+
+// NewBodyBuffer returns a BodyBuffer limited to MaxBodySize.
+func (o *MiddlewareOptions) NewBodyBuffer() *BodyBuffer {
+	if o == nil || o.MaxBodySize <= 0 {
+		return NewBodyBuffer(DefaultMaxBodySize)
+	}
+	return NewBodyBuffer(o.MaxBodySize)
+}
+
+// CaptureRequestBody tees the body of r into the returned buffer as the handler
+// reads it, the body is never read ahead of the handler. It returns nil when
+// request bodies are not captured.
+func (o *MiddlewareOptions) CaptureRequestBody(r *http.Request) *BodyBuffer {
+	if o == nil || !o.RequestBody || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	b := o.NewBodyBuffer()
+	r.Body = &teeReadCloser{Reader: io.TeeReader(r.Body, b), Closer: r.Body}
+	return b
+}
+
+// CaptureResponseBody returns the buffer to tee the response body into. It
+// returns nil when response bodies are not captured.
+func (o *MiddlewareOptions) CaptureResponseBody() *BodyBuffer {
+	if o == nil || !o.ResponseBody {
+		return nil
+	}
+	return o.NewBodyBuffer()
+}
+
+// teeReadCloser closes the original body of a teed request.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureAttrs returns the captured headers and bodies of info to be logged.
+func (o *MiddlewareOptions) captureAttrs(info RequestInfo) []slog.Attr {
+	if o == nil {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	if h := o.headerAttrs(info.RequestHeader, o.RequestHeaders); len(h) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "request_headers", Value: slog.GroupValue(h...)})
+	}
+	if h := o.headerAttrs(info.ResponseHeader, o.ResponseHeaders); len(h) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "response_headers", Value: slog.GroupValue(h...)})
+	}
+	if body, ok := o.body(info.RequestBody, info.RequestHeader); ok {
+		attrs = append(attrs, slog.String("request_body", body))
+	}
+	if body, ok := o.body(info.ResponseBody, info.ResponseHeader); ok {
+		attrs = append(attrs, slog.String("response_body", body))
+	}
+	return attrs
+}
+
+// headerAttrs returns the headers in names, or all headers for "*", with the
+// redacted headers replaced.
+func (o *MiddlewareOptions) headerAttrs(header http.Header, names []string) []slog.Attr {
+	if len(header) == 0 || len(names) == 0 {
+		return nil
+	}
+
+	if slices.Contains(names, "*") {
+		names = make([]string, 0, len(header))
+		for name := range header {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+	}
+
+	redact := o.RedactHeaders
+	if redact == nil {
+		redact = DefaultRedactHeaders
+	}
+
+	var attrs []slog.Attr
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		values, ok := header[name]
+		if !ok {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if slices.ContainsFunc(redact, func(r string) bool { return strings.EqualFold(r, name) }) {
+			value = RedactedValue
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return attrs
+}
+
+// body returns the captured body if its content type is allowed.
+func (o *MiddlewareOptions) body(b *BodyBuffer, header http.Header) (string, bool) {
+	if b == nil || b.Len() == 0 {
+		return "", false
+	}
+
+	// Handlers relying on net/http to sniff the content type leave the header unset
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(b.Bytes())
+	}
+	if !o.allowedContentType(contentType) {
+		return "", false
+	}
+
+	body := string(b.Bytes())
+	if b.Truncated() {
+		body += "... (truncated)"
+	}
+	return body, true
+}
+
+// allowedContentType reports whether bodies of contentType are captured.
+// The allow list entries are matched with path.Match, e.g. text/*.
+func (o *MiddlewareOptions) allowedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	allowed := o.BodyContentTypes
+	if allowed == nil {
+		allowed = DefaultBodyContentTypes
+	}
+	for _, pattern := range allowed {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
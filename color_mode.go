@@ -0,0 +1,88 @@
+package sloghuman
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode is used to determine when the TextHandler colors its output
+type ColorMode int
+
+// Enums used to set the color mode of the TextHandler
+const (
+	// ColorModeAuto colors output only when the writer is a terminal.
+	// The NO_COLOR, FORCE_COLOR, CLICOLOR, CLICOLOR_FORCE and TERM
+	// environment variables are honored.
+	ColorModeAuto ColorMode = iota
+
+	// ColorModeAlways colors output regardless of the writer or environment.
+	ColorModeAlways
+
+	// ColorModeNever never colors output.
+	ColorModeNever
+)
+
+func (m ColorMode) String() string {
+	names := [...]string{"Auto", "Always", "Never"}
+	if m < 0 || int(m) >= len(names) {
+		return fmt.Sprintf("ColorMode(%d)", int(m))
+	}
+	return names[m]
+}
+
+// useColor reports whether a handler writing to out should color its output.
+//
+// In ColorModeAuto the environment is checked in the following order:
+//   - NO_COLOR set to anything other than "false" disables color
+//   - FORCE_COLOR or CLICOLOR_FORCE set to anything other than "0" or "false" enables color,
+//     FORCE_COLOR set to "0" or "false" disables it
+//   - TERM=dumb or CLICOLOR=0 disables color
+//   - otherwise color is used only if out is a terminal
+func useColor(out io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+
+	if v, ok := os.LookupEnv("NO_COLOR"); ok && !envIs(v, "false") {
+		return false
+	}
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		return !envIs(v, "0", "false")
+	}
+	if v, ok := os.LookupEnv("CLICOLOR_FORCE"); ok && !envIs(v, "0", "false") {
+		return true
+	}
+	if envIs(os.Getenv("TERM"), "dumb") {
+		return false
+	}
+	if v, ok := os.LookupEnv("CLICOLOR"); ok && envIs(v, "0") {
+		return false
+	}
+
+	return isTerminal(out)
+}
+
+// envIs reports whether the environment value v matches any of the provided values.
+func envIs(v string, values ...string) bool {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for _, want := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal reports whether w is an *os.File connected to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isTerminalFd(f.Fd())
+}
@@ -30,6 +30,7 @@ type (
 		LevelERROR string
 
 		RequestID string
+		TraceID   string
 		Path      string
 		Line      string
 		LogType   string
@@ -41,162 +42,31 @@ type (
 	ColorType int
 )
 
-// Below are the predefined color palettes that can be used.
-// Set Colors = PaletteName to use them.
+// Below are the predefined color palettes that can be used. They are the
+// predefined themes rendered with 256 colors, use TextOptions.Theme instead
+// to render them at the color depth of the terminal.
+// Set TextOptions.Palette = &PaletteName or Colors = PaletteName to use them.
 var (
 	// Dracula
-	Dracula = ColorPalette{
-		MethodGET:     "\033[38;5;84m",
-		MethodPOST:    "\033[38;5;159m",
-		MethodPUT:     "\033[38;5;222m",
-		MethodDELETE:  "\033[38;5;203m",
-		MethodPATCH:   "\033[38;5;222m",
-		MethodHEAD:    "\033[38;5;84m",
-		MethodOPTIONS: "\033[38;5;141m",
-		MethodTRACE:   "\033[38;5;248m",
-		MethodCONNECT: "\033[38;5;248m",
-
-		Status2xx: "\033[38;5;84m",
-		Status3xx: "\033[38;5;159m",
-		Status4xx: "\033[38;5;222m",
-		Status5xx: "\033[38;5;203m",
-
-		LevelDEBUG: "\033[38;5;159m",
-		LevelINFO:  "\033[38;5;84m",
-		LevelWARN:  "\033[38;5;222m",
-		LevelERROR: "\033[38;5;203m",
-
-		RequestID: "\033[38;5;212m",
-		Path:      "\033[38;5;159m",
-		Line:      "\033[38;5;159m",
-		LogType:   "\033[38;5;141m",
-		Message:   "\033[38;5;141m",
-		Reset:     "\033[0m",
-	}
+	Dracula = DraculaTheme.Palette(ColorDepth256)
 
 	// Nord
-	Nord = ColorPalette{
-		MethodGET:     "\033[38;5;14m",
-		MethodPOST:    "\033[38;5;12m",
-		MethodPUT:     "\033[38;5;11m",
-		MethodDELETE:  "\033[38;5;9m",
-		MethodPATCH:   "\033[38;5;11m",
-		MethodHEAD:    "\033[38;5;14m",
-		MethodOPTIONS: "\033[38;5;13m",
-		MethodTRACE:   "\033[38;5;8m",
-		MethodCONNECT: "\033[38;5;8m",
-
-		Status2xx: "\033[38;5;10m",
-		Status3xx: "\033[38;5;14m",
-		Status4xx: "\033[38;5;11m",
-		Status5xx: "\033[38;5;9m",
-
-		LevelDEBUG: "\033[38;5;14m",
-		LevelINFO:  "\033[38;5;10m",
-		LevelWARN:  "\033[38;5;11m",
-		LevelERROR: "\033[38;5;9m",
-
-		RequestID: "\033[38;5;13m",
-		Path:      "\033[38;5;12m",
-		Line:      "\033[38;5;8m",
-		LogType:   "\033[38;5;6m",
-		Message:   "\033[38;5;6m",
-		Reset:     "\033[0m",
-	}
+	Nord = NordTheme.Palette(ColorDepth256)
 
 	// Gruvbox Dark
-	GruvboxDark = ColorPalette{
-		MethodGET:     "\033[38;5;142m",
-		MethodPOST:    "\033[38;5;108m",
-		MethodPUT:     "\033[38;5;172m",
-		MethodDELETE:  "\033[38;5;167m",
-		MethodPATCH:   "\033[38;5;172m",
-		MethodHEAD:    "\033[38;5;142m",
-		MethodOPTIONS: "\033[38;5;175m",
-		MethodTRACE:   "\033[38;5;248m",
-		MethodCONNECT: "\033[38;5;248m",
-
-		Status2xx: "\033[38;5;142m",
-		Status3xx: "\033[38;5;108m",
-		Status4xx: "\033[38;5;172m",
-		Status5xx: "\033[38;5;167m",
-
-		LevelDEBUG: "\033[38;5;108m",
-		LevelINFO:  "\033[38;5;142m",
-		LevelWARN:  "\033[38;5;172m",
-		LevelERROR: "\033[38;5;167m",
-
-		RequestID: "\033[38;5;175m",
-		Path:      "\033[38;5;109m",
-		Line:      "\033[38;5;248m",
-		LogType:   "\033[38;5;214m",
-		Message:   "\033[38;5;214m",
-		Reset:     "\033[0m",
-	}
+	GruvboxDark = GruvboxDarkTheme.Palette(ColorDepth256)
 
 	// Solarized Dark
-	SolarizedDark = ColorPalette{
-		MethodGET:     "\033[38;5;64m",
-		MethodPOST:    "\033[38;5;37m",
-		MethodPUT:     "\033[38;5;136m",
-		MethodDELETE:  "\033[38;5;160m",
-		MethodPATCH:   "\033[38;5;136m",
-		MethodHEAD:    "\033[38;5;64m",
-		MethodOPTIONS: "\033[38;5;136m",
-		MethodTRACE:   "\033[38;5;244m",
-		MethodCONNECT: "\033[38;5;244m",
-
-		Status2xx: "\033[38;5;64m",
-		Status3xx: "\033[38;5;37m",
-		Status4xx: "\033[38;5;166m",
-		Status5xx: "\033[38;5;160m",
-
-		LevelDEBUG: "\033[38;5;37m",
-		LevelINFO:  "\033[38;5;33m",
-		LevelWARN:  "\033[38;5;166m",
-		LevelERROR: "\033[38;5;160m",
-
-		RequestID: "\033[38;5;136m",
-		Path:      "\033[38;5;33m",
-		Line:      "\033[38;5;244m",
-		LogType:   "\033[38;5;125m",
-		Message:   "\033[38;5;125m",
-		Reset:     "\033[0m",
-	}
+	SolarizedDark = SolarizedDarkTheme.Palette(ColorDepth256)
 
 	// One Dark
-	OneDark = ColorPalette{
-		MethodGET:     "\033[38;5;114m",
-		MethodPOST:    "\033[38;5;81m",
-		MethodPUT:     "\033[38;5;222m",
-		MethodDELETE:  "\033[38;5;204m",
-		MethodPATCH:   "\033[38;5;222m",
-		MethodHEAD:    "\033[38;5;114m",
-		MethodOPTIONS: "\033[38;5;176m",
-		MethodTRACE:   "\033[38;5;145m",
-		MethodCONNECT: "\033[38;5;145m",
-
-		Status2xx: "\033[38;5;114m",
-		Status3xx: "\033[38;5;81m",
-		Status4xx: "\033[38;5;222m",
-		Status5xx: "\033[38;5;204m",
-
-		LevelDEBUG: "\033[38;5;81m",
-		LevelINFO:  "\033[38;5;114m",
-		LevelWARN:  "\033[38;5;222m",
-		LevelERROR: "\033[38;5;204m",
-
-		RequestID: "\033[38;5;176m",
-		Path:      "\033[38;5;81m",
-		Line:      "\033[38;5;145m",
-		LogType:   "\033[38;5;176m",
-		Message:   "\033[38;5;176m",
-		Reset:     "\033[0m",
-	}
+	OneDark = OneDarkTheme.Palette(ColorDepth256)
 
-	// Colors sets the palette to be used by colorize.
-	// This can be changed before creating a new logger by either
-	// creating your own color palette or using a pre-existing palette
+	// Colors sets the default palette used by text handlers that do not set
+	// TextOptions.Palette or TextOptions.Theme. It is copied when the handler is
+	// created so it must be changed before creating a new logger, either by
+	// creating your own color palette or using a pre-existing palette.
+	// Set TextOptions.Theme instead to render a theme at the terminals color depth.
 	Colors ColorPalette = Dracula
 )
 
@@ -211,6 +81,8 @@ const (
 	ColorLine
 	ColorLogType
 	ColorMessage
+	ColorGRPCCode
+	ColorTraceID
 )
 
 // colorize sets the colors for the provided string using the given ColorType
@@ -225,63 +97,85 @@ func (h *TextHandler) colorize(value string, t ColorType) string {
 	case ColorMethod:
 		switch strings.TrimSpace(value) {
 		case http.MethodGet:
-			return Colors.MethodGET + value + Colors.Reset
+			return h.paint(h.palette.MethodGET, value)
 		case http.MethodPost:
-			return Colors.MethodPOST + value + Colors.Reset
+			return h.paint(h.palette.MethodPOST, value)
 		case http.MethodPut:
-			return Colors.MethodPUT + value + Colors.Reset
+			return h.paint(h.palette.MethodPUT, value)
 		case http.MethodDelete:
-			return Colors.MethodDELETE + value + Colors.Reset
+			return h.paint(h.palette.MethodDELETE, value)
 		case http.MethodPatch:
-			return Colors.MethodPATCH + value + Colors.Reset
+			return h.paint(h.palette.MethodPATCH, value)
 		case http.MethodHead:
-			return Colors.MethodHEAD + value + Colors.Reset
+			return h.paint(h.palette.MethodHEAD, value)
 		case http.MethodOptions:
-			return Colors.MethodOPTIONS + value + Colors.Reset
+			return h.paint(h.palette.MethodOPTIONS, value)
 		case http.MethodConnect:
-			return Colors.MethodCONNECT + value + Colors.Reset
+			return h.paint(h.palette.MethodCONNECT, value)
 		case http.MethodTrace:
-			return Colors.MethodTRACE + value + Colors.Reset
+			return h.paint(h.palette.MethodTRACE, value)
 		default:
 			return value
 		}
 	case ColorStatus:
 		switch {
 		case strings.HasPrefix(value, "2"):
-			return Colors.Status2xx + value + Colors.Reset
+			return h.paint(h.palette.Status2xx, value)
 		case strings.HasPrefix(value, "3"):
-			return Colors.Status3xx + value + Colors.Reset
+			return h.paint(h.palette.Status3xx, value)
 		case strings.HasPrefix(value, "4"):
-			return Colors.Status4xx + value + Colors.Reset
+			return h.paint(h.palette.Status4xx, value)
 		case strings.HasPrefix(value, "5"):
-			return Colors.Status5xx + value + Colors.Reset
+			return h.paint(h.palette.Status5xx, value)
 		default:
 			return value
 		}
+	case ColorGRPCCode:
+		switch grpcCodeStatus(value) {
+		case "2xx":
+			return h.paint(h.palette.Status2xx, value)
+		case "3xx":
+			return h.paint(h.palette.Status3xx, value)
+		case "4xx":
+			return h.paint(h.palette.Status4xx, value)
+		default:
+			return h.paint(h.palette.Status5xx, value)
+		}
 	case ColorLevel:
 		switch strings.TrimSpace(value) {
 		case "DEBUG":
-			return Colors.LevelDEBUG + value + Colors.Reset
+			return h.paint(h.palette.LevelDEBUG, value)
 		case "INFO":
-			return Colors.LevelINFO + value + Colors.Reset
+			return h.paint(h.palette.LevelINFO, value)
 		case "WARN":
-			return Colors.LevelWARN + value + Colors.Reset
+			return h.paint(h.palette.LevelWARN, value)
 		case "ERROR":
-			return Colors.LevelERROR + value + Colors.Reset
+			return h.paint(h.palette.LevelERROR, value)
 		default:
 			return value
 		}
 	case ColorLine:
-		return Colors.Line + value + Colors.Reset
+		return h.paint(h.palette.Line, value)
 	case ColorRequestID:
-		return Colors.RequestID + value + Colors.Reset
+		return h.paint(h.palette.RequestID, value)
+	case ColorTraceID:
+		return h.paint(h.palette.TraceID, value)
 	case ColorPath:
-		return Colors.Path + value + Colors.Reset
+		return h.paint(h.palette.Path, value)
 	case ColorLogType:
-		return Colors.LogType + value + Colors.Reset
+		return h.paint(h.palette.LogType, value)
 	case ColorMessage:
-		return Colors.Message + value + Colors.Reset
+		return h.paint(h.palette.Message, value)
 	default:
 		return value
 	}
 }
+
+// paint wraps value in the escape code and the palettes reset.
+// An empty code, such as an unset style, leaves value as is.
+func (h *TextHandler) paint(code, value string) string {
+	if code == "" {
+		return value
+	}
+	return code + value + h.palette.Reset
+}
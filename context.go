@@ -0,0 +1,90 @@
+package sloghuman
+
+import (
+	"context"
+	"log/slog"
+)
+
+type (
+	// ContextExtractor returns the attrs to add to a record from the context
+	// passed to the handler. See TextOptions.ContextExtractors.
+	ContextExtractor func(ctx context.Context) []slog.Attr
+
+	// loggerKey is the context key of the logger stored by WithLogger.
+	loggerKey struct{}
+
+	// requestIDKey is the context key of the request id stored by ContextWithRequestID.
+	requestIDKey struct{}
+)
+
+// RequestIDExtractor adds the request id stored by ContextWithRequestID as request_id.
+var RequestIDExtractor ContextExtractor = func(ctx context.Context) []slog.Attr {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return []slog.Attr{slog.String("request_id", id)}
+	}
+	return nil
+}
+
+// ContextValue returns a ContextExtractor adding the value stored in the context
+// under key as an attr named name. Nothing is added when the value is missing.
+//
+//	TextOpts: &logger.TextOptions{
+//		ContextExtractors: []logger.ContextExtractor{
+//			logger.RequestIDExtractor,
+//			logger.ContextValue(tenantKey{}, "tenant_id"),
+//		},
+//	}
+func ContextValue(key any, name string) ContextExtractor {
+	return func(ctx context.Context) []slog.Attr {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+		return []slog.Attr{slog.Any(name, v)}
+	}
+}
+
+// ContextWithRequestID returns a copy of ctx holding the request id. The
+// middlewares store the id of each request with it.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored by ContextWithRequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithLogger returns a copy of ctx holding logger. The middlewares use it to
+// pass handlers a logger carrying the attrs of the request.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by WithLogger, or slog.Default()
+// if there is none.
+//
+//	func getUser(w http.ResponseWriter, r *http.Request) {
+//		logger.FromContext(r.Context()).Info("loading user") // carries request_id, method and path
+//	}
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// RequestLogger returns logger with the request_id, method and path of info.
+// The request id is left out when it is empty.
+func RequestLogger(logger *slog.Logger, info RequestInfo) *slog.Logger {
+	attrs := make([]any, 0, 3)
+	if info.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", info.RequestID))
+	}
+	attrs = append(attrs,
+		slog.String("method", info.Method),
+		slog.String("path", info.Path),
+	)
+	return logger.With(attrs...)
+}
@@ -0,0 +1,64 @@
+package sloghuman
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// grpcCallRenderer is the built-in renderer for the grpc_call log type.
+var grpcCallRenderer = LogTypeRenderer{
+	Keys: []string{"service", "method", "code", "peer", "duration", "sent", "received"},
+	Render: func(e LogEntry) string {
+		fullMethod := e.Attr("method")
+		if service := e.Attr("service"); service != "" {
+			fullMethod = "/" + service + "/" + fullMethod
+		}
+
+		// Sent and received messages and duration section
+		msgsTime := ""
+		sent, received, duration := e.Attr("sent"), e.Attr("received"), e.Attr("duration")
+		if sent != "" || received != "" || duration != "" {
+			msgsTime = fmt.Sprintf(" [%3s/%-3s %10s] ", sent, received, duration)
+		}
+
+		return fmt.Sprintf("%s | %s %s %s%s %s",
+			e.Colorize("gRPC Call", ColorLogType),
+			e.Colorize(grpcCodeName(e.Attr("code")), ColorGRPCCode),
+			e.Colorize(fullMethod, ColorPath), e.Attr("peer"),
+			msgsTime, e.Colorize(e.Message, ColorMessage),
+		)
+	},
+}
+
+// grpcCodes are the gRPC status code names indexed by their value.
+var grpcCodes = [...]string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss",
+	"Unauthenticated",
+}
+
+// grpcCodeName returns the name of a gRPC status code logged as a number,
+// names are returned as is.
+func grpcCodeName(code string) string {
+	if n, err := strconv.Atoi(code); err == nil && n >= 0 && n < len(grpcCodes) {
+		return grpcCodes[n]
+	}
+	return code
+}
+
+// grpcCodeStatus maps a gRPC status code name to the HTTP status class used to
+// color it. Codes caused by the caller are colored as 4xx, codes caused by the
+// server as 5xx.
+func grpcCodeStatus(code string) string {
+	switch grpcCodeName(code) {
+	case "OK":
+		return "2xx"
+	case "Canceled":
+		return "3xx"
+	case "Unknown", "DeadlineExceeded", "Unimplemented", "Internal", "Unavailable", "DataLoss":
+		return "5xx"
+	default:
+		return "4xx"
+	}
+}
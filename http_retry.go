@@ -0,0 +1,158 @@
+package sloghuman
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of the retries and circuit breaker of HTTPWriter.
+const (
+	DefaultMaxRetries       = 3
+	DefaultRetryBackoff     = 500 * time.Millisecond
+	DefaultMaxRetryBackoff  = 30 * time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// errBreakerOpen is returned for requests not sent because the circuit breaker is open.
+var errBreakerOpen = errors.New("circuit breaker open")
+
+type (
+	// statusError is a response with a status that is not 2xx.
+	statusError struct {
+		status     int
+		retryAfter time.Duration
+	}
+
+	// circuitBreaker stops sending to an endpoint after threshold consecutive
+	// failures. After cooldown a single request is let through, its result
+	// closes the breaker or opens it for another cooldown.
+	circuitBreaker struct {
+		mx        sync.Mutex
+		threshold int
+		cooldown  time.Duration
+		failures  int
+		state     breakerState
+		openedAt  time.Time
+	}
+
+	breakerState int
+)
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("bad status %d", e.status)
+}
+
+// retryable reports whether the request that failed with err can be retried.
+// Network errors, 429 and 5xx are retried, other statuses are the clients fault.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status == http.StatusTooManyRequests || se.status >= 500
+	}
+	return true
+}
+
+// retryDelay returns how long to wait before retry number attempt, starting at 0.
+// The backoff doubles with every attempt up to MaxRetryBackoff and is jittered
+// so writers do not retry in lockstep. A Retry-After sent with err is used as is.
+// It returns false when the server asked to wait longer than MaxRetryBackoff.
+func (o *HTTPWriterOptions) retryDelay(attempt int, err error) (time.Duration, bool) {
+	var se *statusError
+	if errors.As(err, &se) && se.retryAfter > 0 {
+		return se.retryAfter, se.retryAfter <= o.MaxRetryBackoff
+	}
+
+	backoff := o.RetryBackoff << min(attempt, 30)
+	if backoff <= 0 || backoff > o.MaxRetryBackoff {
+		backoff = o.MaxRetryBackoff
+	}
+	// Equal jitter, half of the backoff is random
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds or an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// allow reports whether a request may be sent. A nil breaker always allows it.
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// Only the trial request is let through
+		return false
+	default:
+		return true
+	}
+}
+
+// success records a request that reached the endpoint and closes the breaker.
+func (b *circuitBreaker) success() {
+	if b == nil {
+		return
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.failures = 0
+	b.state = breakerClosed
+}
+
+// failure records a request that failed after all retries. It returns true when
+// the breaker opens.
+func (b *circuitBreaker) failure() bool {
+	if b == nil {
+		return false
+	}
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.threshold) {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		return true
+	}
+	return false
+}
+
+// reportOpen tells the user the breaker opened, the lines dropped while it is
+// open are not reported one by one.
+func (w *HTTPWriter) reportOpen() {
+	fmt.Fprintf(os.Stderr, "[slog-human] http writer: %s is failing, dropping logs for %s\n", w.endpoint, w.opts.BreakerCooldown)
+}
@@ -0,0 +1,399 @@
+package sloghuman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the spool of HTTPWriter.
+const (
+	DefaultSpoolMaxBytes     = 64 << 20
+	DefaultSpoolSegmentBytes = 4 << 20
+)
+
+// spoolExt is the extension of spool segment files.
+const spoolExt = ".ndjson"
+
+type (
+	// spool is a directory of segment files holding the lines of an HTTPWriter
+	// until they are delivered. Lines are appended to the active segment, which
+	// is sealed when it is full or the sender catches up with it. The sender
+	// delivers sealed segments oldest first and removes them once they are sent.
+	spool struct {
+		dir          string
+		maxBytes     int64
+		segmentBytes int64
+
+		// activeSize is the size of the active segment and size the size of
+		// every segment, nextID is the id of the next active segment.
+		mx         sync.Mutex
+		sealed     []spoolSegment
+		active     *os.File
+		activeSize int64
+		size       int64
+		nextID     uint64
+
+		// notify is signaled when lines are appended, flush when Flush wants
+		// them sent without waiting for the flush interval.
+		notify chan struct{}
+		flush  chan struct{}
+	}
+
+	// spoolSegment is a sealed segment file.
+	spoolSegment struct {
+		path string
+		size int64
+	}
+)
+
+// openSpool opens the spool in dir, creating it if needed. Segments left by a
+// previous process are kept to be sent first.
+func openSpool(dir string, maxBytes, segmentBytes int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{
+		dir:          dir,
+		maxBytes:     maxBytes,
+		segmentBytes: segmentBytes,
+		notify:       make(chan struct{}, 1),
+		flush:        make(chan struct{}, 1),
+	}
+
+	// Segment names are zero padded ids so they sort in the order they were written
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, spoolExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, spoolExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		s.sealed = append(s.sealed, spoolSegment{path: filepath.Join(dir, name), size: info.Size()})
+		s.size += info.Size()
+		s.nextID = max(s.nextID, id+1)
+	}
+	slices.SortFunc(s.sealed, func(a, b spoolSegment) int { return strings.Compare(a.path, b.path) })
+
+	if len(s.sealed) > 0 {
+		s.notify <- struct{}{}
+	}
+	return s, nil
+}
+
+// append writes line to the active segment. It returns false when the line
+// does not fit in the spool and was not written.
+func (s *spool) append(line []byte) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.size+int64(len(line)) > s.maxBytes {
+		return false, nil
+	}
+
+	if s.active != nil && s.activeSize > 0 && s.activeSize+int64(len(line)) > s.segmentBytes {
+		if err := s.seal(); err != nil {
+			return false, err
+		}
+	}
+	if s.active == nil {
+		f, err := os.OpenFile(s.segmentPath(s.nextID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return false, err
+		}
+		s.nextID++
+		s.active = f
+		s.activeSize = 0
+	}
+
+	n, err := s.active.Write(line)
+	s.activeSize += int64(n)
+	s.size += int64(n)
+	if err != nil {
+		return false, err
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// next returns the oldest sealed segment, sealing the active segment when it
+// is the only one left.
+func (s *spool) next() (spoolSegment, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if len(s.sealed) == 0 && s.activeSize > 0 {
+		if err := s.seal(); err != nil {
+			return spoolSegment{}, false, err
+		}
+	}
+	if len(s.sealed) == 0 {
+		return spoolSegment{}, false, nil
+	}
+	return s.sealed[0], true, nil
+}
+
+// remove deletes the oldest sealed segment once it has been sent.
+func (s *spool) remove(seg spoolSegment) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.sealed = slices.DeleteFunc(s.sealed, func(o spoolSegment) bool { return o.path == seg.path })
+	s.size -= seg.size
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// rewrite replaces the content of the sealed segment seg with data.
+func (s *spool) rewrite(seg spoolSegment, data []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// The temporary file is ignored by openSpool if the rename never happens
+	tmp := seg.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, seg.path); err != nil {
+		return err
+	}
+
+	s.size -= seg.size - int64(len(data))
+	for i := range s.sealed {
+		if s.sealed[i].path == seg.path {
+			s.sealed[i].size = int64(len(data))
+		}
+	}
+	return nil
+}
+
+// seal closes the active segment and queues it to be sent. The caller must hold s.mx.
+func (s *spool) seal() error {
+	if s.active == nil {
+		return nil
+	}
+	err := s.active.Close()
+	s.sealed = append(s.sealed, spoolSegment{path: s.active.Name(), size: s.activeSize})
+	s.active = nil
+	s.activeSize = 0
+	return err
+}
+
+// close syncs and closes the active segment, it is sent by the next process
+// using the spool.
+func (s *spool) close() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.active == nil {
+		return nil
+	}
+	err := errors.Join(s.active.Sync(), s.active.Close())
+	s.active = nil
+	return err
+}
+
+// pendingBytes returns the size of the lines waiting in the spool.
+func (s *spool) pendingBytes() int64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.size
+}
+
+func (s *spool) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, spoolExt))
+}
+
+// spoolLines writes the lines in p to the spool, dropping those that do not fit.
+// The caller must hold w.mx.
+func (w *HTTPWriter) spoolLines(p []byte) {
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line, p = p[:i+1], p[i+1:]
+		} else {
+			p = nil
+		}
+
+		w.addPending(len(line))
+		ok, err := w.spool.append(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to spool log: %v\n", err)
+		}
+		if !ok {
+			w.dropped.Add(1)
+			w.donePending(len(line))
+		}
+	}
+}
+
+// replay sends the spooled segments in order until the writer is closed.
+// Lines that fail to send stay in the spool and are retried with a growing
+// backoff, only lines rejected by the endpoint are dropped.
+func (w *HTTPWriter) replay() {
+	for {
+		select {
+		case <-w.spool.notify:
+		case <-w.done:
+			return
+		}
+
+		// Let a batch build up unless a flush is waiting
+		t := time.NewTimer(w.opts.FlushInterval)
+		select {
+		case <-t.C:
+		case <-w.spool.flush:
+			t.Stop()
+		case <-w.done:
+			t.Stop()
+			return
+		}
+
+		for !w.closing() {
+			seg, ok, err := w.spool.next()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to seal spool segment: %v\n", err)
+			}
+			if !ok {
+				break
+			}
+			if !w.replaySegment(seg) {
+				return
+			}
+		}
+	}
+}
+
+// replaySegment sends the lines of seg in batches and removes it. It returns
+// false when the writer was closed before the segment was sent.
+func (w *HTTPWriter) replaySegment(seg spoolSegment) bool {
+	// A segment that cannot be read is kept and read again after a backoff,
+	// only a segment that no longer exists is given up
+	data, err := os.ReadFile(seg.path)
+	for failures := 0; err != nil && !errors.Is(err, fs.ErrNotExist); failures++ {
+		if failures == 0 {
+			fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to read spool segment: %v\n", err)
+		}
+		delay, _ := w.opts.retryDelay(failures, err)
+		if !w.sleep(delay) {
+			return false
+		}
+		data, err = os.ReadFile(seg.path)
+	}
+
+	// A line cut short by a crash is dropped
+	if i := bytes.LastIndexByte(data, '\n'); i+1 < len(data) {
+		data = data[:i+1]
+	}
+
+	var lines [][]byte
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		lines = append(lines, data[:i+1])
+		data = data[i+1:]
+	}
+
+	sent := 0
+	for len(lines) > 0 {
+		if w.closing() {
+			w.keepUnsent(seg, lines, sent)
+			return false
+		}
+
+		n, size := w.chunk(lines)
+		p := payload{body: encodeBatch(lines[:n], size, w.opts.BatchFormat), lines: n}
+
+		for failures := 0; ; failures++ {
+			err := w.deliver(p.body)
+			if err == nil {
+				break
+			}
+			if !retryable(err) {
+				fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to send log: %v\n", err)
+				w.dropped.Add(uint64(p.lines))
+				break
+			}
+			delay, _ := w.opts.retryDelay(failures, err)
+			if !w.sleep(min(delay, w.opts.MaxRetryBackoff)) {
+				w.keepUnsent(seg, lines, sent)
+				return false
+			}
+		}
+		lines = lines[n:]
+		sent += size
+		w.donePending(size)
+	}
+
+	if err := w.spool.remove(seg); err != nil {
+		fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to remove spool segment: %v\n", err)
+	}
+	// A line cut short or a segment removed from the spool is never sent
+	if lost := int(seg.size) - sent; lost > 0 {
+		w.donePending(lost)
+	}
+	return true
+}
+
+// keepUnsent rewrites seg with the lines not sent yet, so the next writer using
+// the spool does not send the others again.
+func (w *HTTPWriter) keepUnsent(seg spoolSegment, lines [][]byte, sent int) {
+	if sent == 0 {
+		return
+	}
+	if err := w.spool.rewrite(seg, bytes.Join(lines, nil)); err != nil {
+		fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to rewrite spool segment: %v\n", err)
+	}
+}
+
+// closing reports whether Close has stopped waiting for the lines to be sent.
+func (w *HTTPWriter) closing() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// chunk returns the number of lines sent in the next request and their size.
+func (w *HTTPWriter) chunk(lines [][]byte) (int, int) {
+	if !w.batching() {
+		return 1, len(lines[0])
+	}
+
+	n, size := 0, 0
+	for _, line := range lines {
+		if n > 0 && ((w.opts.BatchSize > 0 && n >= w.opts.BatchSize) ||
+			(w.opts.BatchBytes > 0 && size+len(line) > w.opts.BatchBytes)) {
+			break
+		}
+		n++
+		size += len(line)
+	}
+	return n, size
+}
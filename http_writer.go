@@ -2,61 +2,326 @@ package sloghuman
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// HTTPWriter is an io.Writer that sends log lines to a remote HTTP endpoint.
-// It Buffers input until a new line is seen, then POSTS the complete JSON line.
-// It Supports bit asynchronous and synchronous modes.
-type HTTPWriter struct {
-	client      *http.Client
-	endpoint    string
-	contentType string
-	buffer      bytes.Buffer
-	async       bool
+// DefaultFlushInterval is how long a batch is held when HTTPWriterOptions
+// enables batching without setting FlushInterval.
+const DefaultFlushInterval = time.Second
+
+// Defaults of the queue used by async writers.
+const (
+	DefaultQueueSize = 1024
+	DefaultWorkers   = 2
+)
+
+// DefaultCloseTimeout is how long Close waits for queued lines to be sent when
+// HTTPWriterOptions.CloseTimeout is not set.
+const DefaultCloseTimeout = 5 * time.Second
+
+type (
+	// HTTPWriter is an io.Writer that sends log lines to a remote HTTP endpoint.
+	// It Buffers input until a new line is seen, then POSTS the complete JSON line.
+	// It Supports bit asynchronous and synchronous modes.
+	// With batching enabled lines are collected and sent together, see HTTPWriterOptions.
+	HTTPWriter struct {
+		client      *http.Client
+		endpoint    string
+		contentType string
+		buffer      bytes.Buffer
+		async       bool
+
+		mx         sync.Mutex
+		opts       HTTPWriterOptions
+		batch      [][]byte
+		batchBytes int
+		timer      *time.Timer
+
+		queue   chan payload
+		dropped atomic.Uint64
+		// held holds the payloads dispatched under mx, sent by the caller once
+		// it released mx. senders counts the ones waiting for space in the
+		// queue with OverflowBlock until they are queued or dropped.
+		held    []payload
+		senders sync.WaitGroup
+		breaker *circuitBreaker
+		spool   *spool
+
+		// closed is set under mx once Close has sent the last lines, done is
+		// closed when Close stops waiting to end the retries still running.
+		closed bool
+		done   chan struct{}
+		// replayed is closed when the sender of the spool has stopped.
+		replayed chan struct{}
+
+		// pending counts the requests queued or being sent, or the bytes in the
+		// spool, idle holds the channels of the Flush calls waiting for it to reach zero.
+		pmx     sync.Mutex
+		pending int
+		idle    []chan struct{}
+	}
+
+	// payload is a request body waiting in the queue and the number of lines in it.
+	payload struct {
+		body  []byte
+		lines int
+	}
+
+	// HTTPWriterOptions configures an HTTPWriter created by NewHTTPWriterWithOptions.
+	// Batching is enabled when any of BatchSize, BatchBytes or FlushInterval is set,
+	// a batch is sent as soon as any of the limits is reached.
+	HTTPWriterOptions struct {
+		// Client sends the requests. Defaults to a client with a 5 second timeout.
+		Client *http.Client
+
+		// Async sends requests in the background so Write never waits for the server.
+		// Requests are queued and sent by a fixed number of workers.
+		// Ignored when SpoolDir is set, spooled lines are always sent in the background.
+		Async bool
+
+		// QueueSize is the number of requests an async writer holds while its workers
+		// are busy. Defaults to DefaultQueueSize.
+		QueueSize int
+
+		// Workers is the number of requests an async writer sends at once.
+		// Defaults to DefaultWorkers.
+		Workers int
+
+		// Overflow sets what an async writer does when its queue is full.
+		// Defaults to OverflowDropNewest.
+		Overflow OverflowPolicy
+
+		// BatchSize is the maximum number of lines sent in one request.
+		BatchSize int
+
+		// BatchBytes is the maximum size of the lines sent in one request, before
+		// compression. A single line larger than it is sent on its own.
+		BatchBytes int
+
+		// FlushInterval is the longest a line waits for its batch to fill up.
+		// Defaults to DefaultFlushInterval when batching.
+		FlushInterval time.Duration
+
+		// BatchFormat sets how batched lines are encoded. Defaults to BatchFormatNDJSON.
+		BatchFormat BatchFormat
+
+		// Gzip compresses request bodies and sets Content-Encoding: gzip.
+		Gzip bool
+
+		// MaxRetries is the number of times a request failing with a network error,
+		// 429 or 5xx is retried. Other statuses are never retried. Zero disables retries.
+		MaxRetries int
+
+		// RetryBackoff is the delay before the first retry, it doubles with every
+		// retry and is jittered. Defaults to DefaultRetryBackoff.
+		RetryBackoff time.Duration
+
+		// MaxRetryBackoff caps the delay between retries. A Retry-After header
+		// asking for longer ends the retries. Defaults to DefaultMaxRetryBackoff.
+		MaxRetryBackoff time.Duration
+
+		// BreakerThreshold is the number of requests in a row that have to fail,
+		// after their retries, for the writer to stop sending for BreakerCooldown.
+		// Lines written in the meantime are dropped. Zero disables the breaker.
+		BreakerThreshold int
+
+		// BreakerCooldown is how long the breaker stays open before a request is
+		// sent to check on the endpoint. Defaults to DefaultBreakerCooldown.
+		BreakerCooldown time.Duration
+
+		// CloseTimeout is how long Close waits for the lines to be sent.
+		// Defaults to DefaultCloseTimeout.
+		CloseTimeout time.Duration
+
+		// SpoolDir enables the spool, a directory where lines are written before
+		// they are sent and kept until they are delivered. Lines that fail to send
+		// are retried until they are, in order, and lines left by a previous process
+		// are sent first. Lines rejected by the endpoint with a 4xx are dropped.
+		// The directory must not be shared by writers.
+		SpoolDir string
+
+		// SpoolMaxBytes is the size of the spool, lines written while it is full are
+		// dropped. Defaults to DefaultSpoolMaxBytes.
+		SpoolMaxBytes int64
+
+		// SpoolSegmentBytes is the size of the segment files of the spool.
+		// Defaults to DefaultSpoolSegmentBytes.
+		SpoolSegmentBytes int64
+	}
+
+	// BatchFormat is the encoding of the body of a batch.
+	BatchFormat int
+
+	// OverflowPolicy is what an async HTTPWriter does with lines written while its queue is full.
+	OverflowPolicy int
+)
+
+// Enums used to set the overflow policy of async writers
+const (
+	// OverflowDropNewest drops the lines being written, keeping the queue as is.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued lines to make room.
+	OverflowDropOldest
+	// OverflowBlock makes Write wait for room in the queue.
+	OverflowBlock
+)
+
+func (p OverflowPolicy) String() string {
+	names := [...]string{"DropNewest", "DropOldest", "Block"}
+	if p < 0 || int(p) >= len(names) {
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+	return names[p]
 }
 
-// NewAsyncHTTPWriter returns an io.Writer that sends logs asynchronously over HTTP.
-//
-// This is the recommended mode for production use. Logs are sent in a goroutine, so they
-// never block is slow down the program.
-func NewAsyncHTTPWriter(endpoint string) io.Writer {
-	return &HTTPWriter{
-		client: &http.Client{
-			Timeout: 5 * time.Second,
-		},
-		endpoint:    endpoint,
-		contentType: "application/json",
-		async:       true,
+// Enums used to set how batches are encoded
+const (
+	// BatchFormatNDJSON sends the lines as is, one JSON value per line.
+	BatchFormatNDJSON BatchFormat = iota
+	// BatchFormatJSONArray sends the lines as the elements of a JSON array.
+	// It requires a LoggerTypeJSON handler.
+	BatchFormatJSONArray
+)
+
+func (f BatchFormat) String() string {
+	names := [...]string{"NDJSON", "JSONArray"}
+	if f < 0 || int(f) >= len(names) {
+		return fmt.Sprintf("BatchFormat(%d)", int(f))
 	}
+	return names[f]
 }
 
-// NewHTTPWriter returns an io.Writer that sends logs synchronously over HTTP.
+// NewAsyncHTTPWriter returns an HTTPWriter that sends logs asynchronously over HTTP.
+//
+// This is the recommended mode for production use. Logs are queued and sent by background
+// workers, so they never block is slow down the program. Failed requests are retried
+// up to DefaultMaxRetries times and a circuit breaker stops sending to an endpoint that
+// keeps failing. Lines that could not be sent are dropped and counted by Dropped.
+func NewAsyncHTTPWriter(endpoint string) *HTTPWriter {
+	return NewHTTPWriterWithOptions(endpoint, &HTTPWriterOptions{
+		Async:            true,
+		MaxRetries:       DefaultMaxRetries,
+		BreakerThreshold: DefaultBreakerThreshold,
+	})
+}
+
+// NewHTTPWriter returns an HTTPWriter that sends logs synchronously over HTTP.
 //
 // This mode blocks until the HTTP request completes. Use only for testing
 // or when you absolutely need confirmation that logs are sent.
 // WARNING: Can introduce performance issues or race conditions.
-func NewHTTPWriter(endpoint string) io.Writer {
-	return &HTTPWriter{
+func NewHTTPWriter(endpoint string) *HTTPWriter {
+	return NewHTTPWriterWithOptions(endpoint, nil)
+}
+
+// NewHTTPWriterWithOptions returns an HTTPWriter configured by opts. A nil opts
+// sends every line synchronously, like NewHTTPWriter.
+//
+//	w := logger.NewHTTPWriterWithOptions("https://logs.example.com/intake", &logger.HTTPWriterOptions{
+//		Async:         true,
+//		BatchSize:     500,
+//		BatchBytes:    1 << 20,
+//		FlushInterval: 2 * time.Second,
+//		Gzip:          true,
+//	})
+func NewHTTPWriterWithOptions(endpoint string, opts *HTTPWriterOptions) *HTTPWriter {
+	w := &HTTPWriter{
 		client: &http.Client{
 			Timeout: 5 * time.Second,
 		},
 		endpoint:    endpoint,
 		contentType: "application/json",
-		async:       false,
+		done:        make(chan struct{}),
+	}
+	if opts == nil {
+		return w
+	}
+
+	w.opts = *opts
+	w.async = opts.Async
+	if opts.Client != nil {
+		w.client = opts.Client
+	}
+	if w.opts.SpoolDir != "" {
+		if w.opts.SpoolMaxBytes <= 0 {
+			w.opts.SpoolMaxBytes = DefaultSpoolMaxBytes
+		}
+		if w.opts.SpoolSegmentBytes <= 0 {
+			w.opts.SpoolSegmentBytes = DefaultSpoolSegmentBytes
+		}
+		s, err := openSpool(w.opts.SpoolDir, w.opts.SpoolMaxBytes, w.opts.SpoolSegmentBytes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[slog-human] warning: http writer spool: %v - sending from memory\n", err)
+		} else {
+			w.spool = s
+			w.async = false
+			w.addPending(int(s.pendingBytes()))
+		}
+	}
+	if w.async {
+		if w.opts.QueueSize <= 0 {
+			w.opts.QueueSize = DefaultQueueSize
+		}
+		if w.opts.Workers <= 0 {
+			w.opts.Workers = DefaultWorkers
+		}
+		w.queue = make(chan payload, w.opts.QueueSize)
+		for range w.opts.Workers {
+			go w.work()
+		}
+	}
+	if w.opts.RetryBackoff <= 0 {
+		w.opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if w.opts.MaxRetryBackoff <= 0 {
+		w.opts.MaxRetryBackoff = DefaultMaxRetryBackoff
+	}
+	if w.opts.BreakerThreshold > 0 {
+		if w.opts.BreakerCooldown <= 0 {
+			w.opts.BreakerCooldown = DefaultBreakerCooldown
+		}
+		w.breaker = &circuitBreaker{threshold: w.opts.BreakerThreshold, cooldown: w.opts.BreakerCooldown}
+	}
+	if w.batching() {
+		if w.opts.FlushInterval <= 0 {
+			w.opts.FlushInterval = DefaultFlushInterval
+		}
+		if w.opts.BatchFormat == BatchFormatNDJSON {
+			w.contentType = "application/x-ndjson"
+		}
+	}
+	if w.spool != nil {
+		w.replayed = make(chan struct{})
+		go func() {
+			defer close(w.replayed)
+			w.replay()
+		}()
 	}
+	return w
 }
 
 // Write implements io.Writer. It buffers input until a newline '\n' is detected
-// then sends the complete log line over HTTP.
+// then sends the complete log line over HTTP, or adds it to the batch.
 //
 // Both slog's built-in JSONHandler and this package's TextHandler append '\n'
-// to each record, this ensures one HTTP request per log entry.
+// to each record, this ensures one HTTP request per log entry when not batching.
+// Writing to a closed writer returns os.ErrClosed.
 func (w *HTTPWriter) Write(p []byte) (int, error) {
+	w.mx.Lock()
+	if w.closed {
+		w.mx.Unlock()
+		return 0, os.ErrClosed
+	}
+
 	w.buffer.Write(p)
 
 	if len(p) > 0 && p[len(p)-1] == '\n' {
@@ -64,35 +329,402 @@ func (w *HTTPWriter) Write(p []byte) (int, error) {
 		copy(line, w.buffer.Bytes())
 		w.buffer.Reset()
 
-		if w.async {
-			go w.send(line)
-		} else {
-			w.send(line)
+		switch {
+		case w.spool != nil:
+			w.spoolLines(line)
+		case w.batching():
+			w.add(line)
+		default:
+			w.dispatch(payload{body: line, lines: bytes.Count(line, []byte{'\n'})})
 		}
 	}
+	held := w.takeHeld()
+	w.mx.Unlock()
 
+	// The request, or the wait for space in the queue with OverflowBlock, does
+	// not hold mx so other writes and Flush are not stuck behind it
+	w.sendHeld(held)
 	return len(p), nil
 }
 
-// send performs the actual HTTP POST of a log line.
-// Errors and non-2xx responses are reported to os.Stderr but do not return errors.
-// Logging should never fail the program.
-func (w *HTTPWriter) send(payload []byte) {
-	req, err := http.NewRequest("POST", w.endpoint, bytes.NewReader(payload))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to create request: %v\n", err)
+// Flush sends the buffered partial line and the current batch, then waits until
+// every queued line has been sent or ctx is done, in which case ctx.Err() is returned.
+// Lines that fail to send after their retries count as sent.
+func (w *HTTPWriter) Flush(ctx context.Context) error {
+	w.mx.Lock()
+	w.flushBuffer()
+	held := w.takeHeld()
+	w.mx.Unlock()
+
+	// The held payloads are pending so wait covers them
+	if len(held) > 0 {
+		go w.sendHeld(held)
+	}
+	if w.spool != nil {
+		select {
+		case w.spool.flush <- struct{}{}:
+		default:
+		}
+	}
+	return w.wait(ctx)
+}
+
+// Close flushes the writer, waiting up to CloseTimeout, and stops its
+// workers. Retries still running when the timeout is reached are abandoned,
+// spooled lines are kept for the next writer using the spool.
+// Close returns the error of the flush and is a no-op on a closed writer.
+func (w *HTTPWriter) Close() error {
+	timeout := w.opts.CloseTimeout
+	if timeout <= 0 {
+		timeout = DefaultCloseTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return w.closeContext(ctx)
+}
+
+// closeContext closes the writer like Close, waiting for the flush until ctx is done.
+func (w *HTTPWriter) closeContext(ctx context.Context) error {
+	w.mx.Lock()
+	if w.closed {
+		w.mx.Unlock()
+		return nil
+	}
+	w.flushBuffer()
+	w.closed = true
+	held := w.takeHeld()
+	w.mx.Unlock()
+	unregisterFlusher(w)
+
+	if len(held) > 0 {
+		go w.sendHeld(held)
+	}
+
+	err := w.Flush(ctx)
+
+	// Nothing is dispatched once closed is set, the held payloads still
+	// retrying or waiting for space give up when done is closed
+	close(w.done)
+	if w.queue != nil {
+		w.senders.Wait()
+		close(w.queue)
+	}
+	if w.spool != nil {
+		// Wait for the request in flight so the spool is left as it was sent
+		<-w.replayed
+		err = errors.Join(err, w.spool.close())
+	}
+	return err
+}
+
+// flushBuffer sends the partial line in the buffer, ending it with a newline,
+// and the current batch. The caller must hold w.mx.
+func (w *HTTPWriter) flushBuffer() {
+	if w.buffer.Len() > 0 {
+		line := make([]byte, w.buffer.Len(), w.buffer.Len()+1)
+		copy(line, w.buffer.Bytes())
+		line = append(line, '\n')
+		w.buffer.Reset()
+
+		switch {
+		case w.spool != nil:
+			w.spoolLines(line)
+		case w.batching():
+			w.add(line)
+		default:
+			w.dispatch(payload{body: line, lines: 1})
+		}
+	}
+	w.flush()
+}
+
+// wait waits until no requests are pending or ctx is done.
+func (w *HTTPWriter) wait(ctx context.Context) error {
+	w.pmx.Lock()
+	if w.pending == 0 {
+		w.pmx.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	w.idle = append(w.idle, idle)
+	w.pmx.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// addPending counts n queued requests or spooled bytes.
+func (w *HTTPWriter) addPending(n int) {
+	w.pmx.Lock()
+	w.pending += n
+	w.pmx.Unlock()
+}
+
+// donePending counts n requests or bytes as sent or dropped and wakes the
+// waiting Flush calls when none are left.
+func (w *HTTPWriter) donePending(n int) {
+	w.pmx.Lock()
+	defer w.pmx.Unlock()
+
+	w.pending -= n
+	if w.pending == 0 {
+		for _, idle := range w.idle {
+			close(idle)
+		}
+		w.idle = nil
+	}
+}
+
+// batching reports whether lines are batched.
+func (w *HTTPWriter) batching() bool {
+	return w.opts.BatchSize > 0 || w.opts.BatchBytes > 0 || w.opts.FlushInterval > 0
+}
+
+// add adds the lines in p to the batch, sending it whenever a limit is reached.
+// The caller must hold w.mx.
+func (w *HTTPWriter) add(p []byte) {
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line, p = p[:i+1], p[i+1:]
+		} else {
+			p = nil
+		}
+
+		if w.opts.BatchBytes > 0 && len(w.batch) > 0 && w.batchBytes+len(line) > w.opts.BatchBytes {
+			w.flush()
+		}
+
+		w.batch = append(w.batch, line)
+		w.batchBytes += len(line)
+		if w.timer == nil {
+			w.timer = time.AfterFunc(w.opts.FlushInterval, w.flushTimer)
+		}
+
+		if (w.opts.BatchSize > 0 && len(w.batch) >= w.opts.BatchSize) ||
+			(w.opts.BatchBytes > 0 && w.batchBytes >= w.opts.BatchBytes) {
+			w.flush()
+		}
+	}
+}
+
+// flushTimer sends the batch once FlushInterval has passed since its first line.
+func (w *HTTPWriter) flushTimer() {
+	w.mx.Lock()
+	w.flush()
+	held := w.takeHeld()
+	w.mx.Unlock()
+
+	w.sendHeld(held)
+}
+
+// flush sends the current batch. The caller must hold w.mx.
+func (w *HTTPWriter) flush() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.batch) == 0 {
+		return
+	}
+
+	p := payload{body: encodeBatch(w.batch, w.batchBytes, w.opts.BatchFormat), lines: len(w.batch)}
+	w.batch = nil
+	w.batchBytes = 0
+	w.dispatch(p)
+}
+
+// encodeBatch joins lines into the body of a request.
+func encodeBatch(lines [][]byte, size int, format BatchFormat) []byte {
+	if format != BatchFormatJSONArray {
+		return bytes.Join(lines, nil)
+	}
+
+	var b bytes.Buffer
+	b.Grow(size + 2)
+	b.WriteByte('[')
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(bytes.TrimRight(line, "\r\n"))
+	}
+	b.WriteByte(']')
+	return b.Bytes()
+}
+
+// dispatch queues p when async. Otherwise, or with OverflowBlock, p is held and
+// the caller sends or queues it with sendHeld once it released w.mx, so writes,
+// Flush and Close are not stuck behind retries or a full queue.
+// The caller must hold w.mx.
+func (w *HTTPWriter) dispatch(p payload) {
+	w.addPending(1)
+	if !w.async {
+		w.held = append(w.held, p)
 		return
 	}
+
+	switch w.opts.Overflow {
+	case OverflowBlock:
+		w.senders.Add(1)
+		w.held = append(w.held, p)
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- p:
+				return
+			default:
+			}
+			// The workers may empty the queue in the meantime
+			select {
+			case old := <-w.queue:
+				w.dropped.Add(uint64(old.lines))
+				w.donePending(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case w.queue <- p:
+		default:
+			w.dropped.Add(uint64(p.lines))
+			w.donePending(1)
+		}
+	}
+}
+
+// takeHeld returns the payloads held by dispatch. The caller must hold w.mx.
+func (w *HTTPWriter) takeHeld() []payload {
+	held := w.held
+	w.held = nil
+	return held
+}
+
+// sendHeld sends the payloads held by dispatch, or waits for space in the
+// queue for them when async. Payloads still waiting for the queue when the
+// writer is closed are dropped.
+func (w *HTTPWriter) sendHeld(held []payload) {
+	for _, p := range held {
+		if !w.async {
+			w.send(p)
+			w.donePending(1)
+			continue
+		}
+
+		select {
+		case w.queue <- p:
+		case <-w.done:
+			w.dropped.Add(uint64(p.lines))
+			w.donePending(1)
+		}
+		w.senders.Done()
+	}
+}
+
+// work sends queued requests.
+func (w *HTTPWriter) work() {
+	for p := range w.queue {
+		w.send(p)
+		w.donePending(1)
+	}
+}
+
+// Dropped returns the number of lines that were not sent, because the queue of an
+// async writer was full, the circuit breaker was open or the request failed.
+func (w *HTTPWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// send performs the actual HTTP POST of a log line or batch, retrying it as
+// configured. Errors and non-2xx responses are reported to os.Stderr but do not
+// return errors. Logging should never fail the program.
+func (w *HTTPWriter) send(p payload) {
+	if err := w.deliver(p.body); err != nil {
+		// Lines dropped while the breaker is open are not reported one by one
+		if !errors.Is(err, errBreakerOpen) {
+			fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to send log: %v\n", err)
+		}
+		w.dropped.Add(uint64(p.lines))
+	}
+}
+
+// deliver sends payload, retrying it as configured, and records the result with
+// the circuit breaker.
+func (w *HTTPWriter) deliver(payload []byte) error {
+	if !w.breaker.allow() {
+		return errBreakerOpen
+	}
+
+	body := payload
+	if w.opts.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = w.post(body); err == nil || !retryable(err) || attempt >= w.opts.MaxRetries {
+			break
+		}
+		delay, ok := w.opts.retryDelay(attempt, err)
+		if !ok || !w.sleep(delay) {
+			break
+		}
+	}
+
+	switch {
+	case err == nil, !retryable(err):
+		// The endpoint is up, even if it rejected the request
+		w.breaker.success()
+	case w.breaker.failure():
+		w.reportOpen()
+	}
+	return err
+}
+
+// sleep waits for d and reports whether the writer is still open.
+func (w *HTTPWriter) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// post sends a single request with body.
+func (w *HTTPWriter) post(body []byte) error {
+	req, err := http.NewRequest("POST", w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
 	req.Header.Set("Content-Type", w.contentType)
+	if w.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 
 	resp, err := w.client.Do(req)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[slog-human] http writer: failed to send log: %v\n", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
 
 	if resp.StatusCode >= 400 {
-		fmt.Fprintf(os.Stderr, "[slog-human] http writer: bad status %d sending log\n", resp.StatusCode)
+		return &statusError{status: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
+	return nil
 }
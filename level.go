@@ -0,0 +1,66 @@
+package sloghuman
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+type (
+	// LevelController is a handle used to change the minimum level of every
+	// handler created by NewLoggerMultiHandler while the program is running.
+	// Use Levels to access it.
+	LevelController struct {
+		set   atomic.Bool
+		level slog.LevelVar
+	}
+
+	// controlledLevel is the slog.Leveler given to each handler created by
+	// NewLoggerMultiHandler. It reports the LevelController override when one
+	// is set, otherwise the level from the handler options.
+	controlledLevel struct {
+		base slog.Leveler
+	}
+)
+
+// levels is the package wide LevelController returned by Levels.
+var levels LevelController
+
+// Levels returns the LevelController for all handlers created by NewLoggerMultiHandler.
+//
+//	logger.Levels().Set(slog.LevelDebug) // everything logs at DEBUG
+//	logger.Levels().Reset()              // back to each handler's own level
+func Levels() *LevelController {
+	return &levels
+}
+
+// Set overrides the minimum level of every handler.
+func (c *LevelController) Set(level slog.Level) {
+	c.level.Set(level)
+	c.set.Store(true)
+}
+
+// Reset removes the override set with Set. Each handler goes back to using
+// the level from its slog.HandlerOptions.
+func (c *LevelController) Reset() {
+	c.set.Store(false)
+}
+
+// Level returns the current override and whether one is set.
+func (c *LevelController) Level() (slog.Level, bool) {
+	if !c.set.Load() {
+		return 0, false
+	}
+	return c.level.Level(), true
+}
+
+// Level implements slog.Leveler. A nil base level defaults to slog.LevelInfo
+// the same as slog.HandlerOptions.
+func (l *controlledLevel) Level() slog.Level {
+	if level, ok := levels.Level(); ok {
+		return level
+	}
+	if l.base == nil {
+		return slog.LevelInfo
+	}
+	return l.base.Level()
+}
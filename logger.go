@@ -3,6 +3,7 @@ package sloghuman
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -10,7 +11,6 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -18,20 +18,66 @@ import (
 type (
 	// TextHandler is used to create and implement the slog-human TextHandler
 	TextHandler struct {
-		mx        sync.Mutex
+		mx        *sync.Mutex
 		out       io.Writer
-		level     slog.Level
+		level     slog.Leveler
 		addSource bool
-		attrs     []slog.Attr
-		group     string
+		attrs     []field
+		groups    []string
 		noColor   bool
+
+		groupStyle   GroupStyle
+		palette      ColorPalette
+		prettyBodies bool
+		slowQuery    time.Duration
+		extractors   []ContextExtractor
+
+		replaceAttr func(groups []string, a slog.Attr) slog.Attr
 	}
 
 	// Handler is use to create and acccess a logger handler(s)
 	Handler struct {
-		Type   LoggerType
-		Writer io.Writer
-		Opts   *slog.HandlerOptions
+		Type     LoggerType
+		Writer   io.Writer
+		Opts     *slog.HandlerOptions
+		TextOpts *TextOptions
+	}
+
+	// TextOptions are the options used by the slog-human TextHandler.
+	// They are ignored by LoggerTypeJSON handlers.
+	TextOptions struct {
+		// GroupStyle sets how groups are rendered. Defaults to GroupStyleDotted.
+		GroupStyle GroupStyle
+
+		// Palette sets the colors used by this handler. It is copied when the
+		// handler is created. Defaults to the palette in Colors.
+		Palette *ColorPalette
+
+		// Theme sets the colors used by this handler, rendered at ColorDepth.
+		// Ignored if Palette is set. When neither is set the theme named by the
+		// SLOG_HUMAN_THEME environment variable is used if present.
+		Theme *Theme
+
+		// ColorDepth sets the number of colors Theme is rendered with.
+		// Defaults to ColorDepthAuto which detects it from COLORTERM and TERM.
+		ColorDepth ColorDepth
+
+		// ColorMode sets when output is colored. Defaults to ColorModeAuto
+		// which only colors output written to a terminal.
+		ColorMode ColorMode
+
+		// PrettyBodies indents JSON request and response bodies captured by the
+		// middlewares. Other bodies are printed as is.
+		PrettyBodies bool
+
+		// SlowQuery is the duration above which sql_query entries are flagged
+		// as slow. Defaults to DefaultSlowQuery, a negative value disables it.
+		SlowQuery time.Duration
+
+		// ContextExtractors add attrs from the context passed to the handler to
+		// every record, e.g. RequestIDExtractor. A request_id attr fills the
+		// [reqID] prefix.
+		ContextExtractors []ContextExtractor
 	}
 
 	// multiHandler is used by slog-human to acccess all handlers created
@@ -79,6 +125,7 @@ func NewDefaultLoggerTo(w io.Writer) *slog.Logger {
 }
 
 // NewLoggerMultiHandler returns a new logger with the provided handler(s).
+// Writers that buffer lines, such as HTTPWriter, are flushed by Shutdown.
 // NOTE: If a handler has a nil writer it will be defaulted to os.Stdout with a warning to os.Stderr
 func NewLoggerMultiHandler(handlers ...Handler) *slog.Logger {
 	var slogHandlers []slog.Handler
@@ -89,6 +136,8 @@ func NewLoggerMultiHandler(handlers ...Handler) *slog.Logger {
 			t.Writer = os.Stdout
 		}
 
+		registerFlusher(t.Writer)
+
 		if t.Opts == nil {
 			t.Opts = &slog.HandlerOptions{
 				Level:     slog.LevelInfo,
@@ -96,11 +145,16 @@ func NewLoggerMultiHandler(handlers ...Handler) *slog.Logger {
 			}
 		}
 
+		// Wrap the configured level so it can be changed at runtime using Levels().
+		opts := *t.Opts
+		opts.Level = &controlledLevel{base: opts.Level}
+		t.Opts = &opts
+
 		switch t.Type {
 		case LoggerTypeText:
-			slogHandlers = append(slogHandlers, newTextHandler(t.Writer, t.Opts))
+			slogHandlers = append(slogHandlers, newTextHandler(t.Writer, t.Opts, t.TextOpts))
 		case LoggerTypeJSON:
-			slogHandlers = append(slogHandlers, slog.NewJSONHandler(t.Writer, t.Opts))
+			slogHandlers = append(slogHandlers, newTraceHandler(slog.NewJSONHandler(t.Writer, t.Opts)))
 		}
 	}
 
@@ -117,135 +171,192 @@ func NewLoggerMultiHandler(handlers ...Handler) *slog.Logger {
 
 // newTextHandler is the internal helper that creates the text handler used
 // by slog-human to print text logs.
-func newTextHandler(out io.Writer, opts *slog.HandlerOptions) slog.Handler {
-	noColor := false
-	if v, ok := os.LookupEnv("NO_COLOR"); ok {
-		if strings.ToLower(strings.TrimSpace(v)) != "false" {
-			noColor = true
+func newTextHandler(out io.Writer, opts *slog.HandlerOptions, textOpts *TextOptions) slog.Handler {
+	if textOpts == nil {
+		textOpts = &TextOptions{}
+	}
+
+	palette := Colors
+	switch {
+	case textOpts.Palette != nil:
+		palette = *textOpts.Palette
+	case textOpts.Theme != nil:
+		palette = textOpts.Theme.Palette(textOpts.ColorDepth)
+	default:
+		envTheme, err := themeFromEnv()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[slog-human] warning: %s: %v - using default theme\n", ThemeEnv, err)
 		}
+		if envTheme != nil {
+			palette = envTheme.Palette(textOpts.ColorDepth)
+		}
+	}
+
+	slowQuery := textOpts.SlowQuery
+	if slowQuery == 0 {
+		slowQuery = DefaultSlowQuery
 	}
 
 	return &TextHandler{
-		out:       out,
-		level:     opts.Level.Level(),
-		addSource: opts.AddSource,
-		noColor:   noColor,
+		mx:           &sync.Mutex{},
+		out:          out,
+		level:        opts.Level,
+		addSource:    opts.AddSource,
+		noColor:      !useColor(out, textOpts.ColorMode),
+		replaceAttr:  opts.ReplaceAttr,
+		groupStyle:   textOpts.GroupStyle,
+		palette:      palette,
+		prettyBodies: textOpts.PrettyBodies,
+		slowQuery:    slowQuery,
+		extractors:   textOpts.ContextExtractors,
 	}
 }
 
 // Enabled is the slog-human implementation of slog.Handler interface
 func (h *TextHandler) Enabled(_ context.Context, level slog.Level) bool {
-	return level >= h.level
+	minLevel := slog.LevelInfo
+	if h.level != nil {
+		minLevel = h.level.Level()
+	}
+	return level >= minLevel
 }
 
 // Handle is the slog-human implementation of slog.Handler interface.
 // The known attrs will all be colored according to the color palette in
 // use. See colorize.go for more.
-func (h *TextHandler) Handle(_ context.Context, r slog.Record) error {
-	h.mx.Lock()
-	defer h.mx.Unlock()
+func (h *TextHandler) Handle(ctx context.Context, r slog.Record) error {
+	// The log type selects the renderer, which decides which of the top level
+	// attrs it consumes. Record attrs take precedence over handler attrs.
+	var recordFields []field
+	r.Attrs(func(attr slog.Attr) bool {
+		recordFields = h.appendAttr(recordFields, h.groups, attr)
+		return true
+	})
+
+	// Context attrs are always top level, they are not part of the handlers groups
+	var contextFields []field
+	for _, extract := range h.extractors {
+		if ctx == nil {
+			break
+		}
+		for _, attr := range extract(ctx) {
+			contextFields = h.appendAttr(contextFields, nil, attr)
+		}
+	}
+
+	logType := ""
+	if v, ok := lastPredefined(recordFields, "log_type"); ok {
+		logType = v.String()
+	} else if v, ok := lastPredefined(contextFields, "log_type"); ok {
+		logType = v.String()
+	} else if v, ok := lastPredefined(h.attrs, "log_type"); ok {
+		logType = v.String()
+	}
+	renderer := lookupLogType(logType)
+
+	consumed := map[string]struct{}{"log_type": {}, "request_id": {}, "trace_id": {}}
+	for _, key := range renderer.Keys {
+		consumed[key] = struct{}{}
+	}
 
 	var (
-		logType   string
-		method    string
-		path      string
-		status    string
-		remote    string
-		duration  string
-		requestID string
-		bytes     string
-		fields    []string
-		seen      = make(map[string]struct{})
+		values = make(map[string]slog.Value)
+		fields []field
+		seen   = make(map[string]struct{})
 	)
 
 	// Record attrs
-	r.Attrs(func(attr slog.Attr) bool {
-		switch attr.Key {
-		case "log_type":
-			logType = attr.Value.String()
-		case "method":
-			method = attr.Value.String()
-		case "path":
-			path = attr.Value.String()
-		case "status":
-			status = attr.Value.String()
-		case "duration":
-			if d, ok := attr.Value.Any().(time.Duration); ok {
-				duration = d.String()
-			} else {
-				duration = attr.Value.String()
+	for _, f := range recordFields {
+		if key := f.predefinedKey(); key != "" {
+			if _, ok := consumed[key]; ok {
+				values[key] = f.attr.Value
+				continue
 			}
-		case "remote":
-			remote = attr.Value.String()
-		case "request_id":
-			requestID = attr.Value.String()
-		case "bytes":
-			bytes = attr.Value.String()
-		default:
-			fields = append(fields, fmt.Sprintf("%s=%v", attr.Key, attr.Value))
-			seen[attr.Key] = struct{}{}
 		}
-		return true
-	})
+		fields = append(fields, f)
+		seen[f.path()] = struct{}{}
+	}
 
-	// Handler attrs
-	for _, attr := range h.attrs {
-		switch attr.Key {
-		case "log_type":
-			if logType == "" {
-				logType = attr.Value.String()
-			}
-		case "method":
-			if method == "" {
-				method = attr.Value.String()
-			}
-		case "path":
-			if path == "" {
-				path = attr.Value.String()
-			}
-		case "status":
-			if status == "" {
-				status = attr.Value.String()
-			}
-		case "duration":
-			if duration == "" {
-				if d, ok := attr.Value.Any().(time.Duration); ok {
-					duration = d.String()
-				} else {
-					duration = attr.Value.String()
+	// Context attrs
+	for _, f := range contextFields {
+		if key := f.predefinedKey(); key != "" {
+			if _, ok := consumed[key]; ok {
+				if _, set := values[key]; !set {
+					values[key] = f.attr.Value
 				}
+				continue
 			}
-		case "remote":
-			if remote == "" {
-				remote = attr.Value.String()
-			}
-		case "request_id":
-			if requestID == "" {
-				requestID = attr.Value.String()
-			}
-		case "bytes":
-			if bytes == "" {
-				bytes = attr.Value.String()
-			}
-		default:
-			if _, dup := seen[attr.Key]; !dup {
-				fields = append(fields, fmt.Sprintf("%s=%v", attr.Key, attr.Value))
+		}
+		if _, dup := seen[f.path()]; !dup {
+			fields = append(fields, f)
+			seen[f.path()] = struct{}{}
+		}
+	}
+
+	// Handler attrs
+	for _, f := range h.attrs {
+		if key := f.predefinedKey(); key != "" {
+			if _, ok := consumed[key]; ok {
+				if _, set := values[key]; !set {
+					values[key] = f.attr.Value
+				}
+				continue
 			}
 		}
+		if _, dup := seen[f.path()]; !dup {
+			fields = append(fields, f)
+		}
+	}
+
+	requestID := ""
+	if v, ok := values["request_id"]; ok {
+		requestID = v.String()
+	}
+
+	// The trace is read from the context even without a TraceExtractor
+	traceID := ""
+	if v, ok := values["trace_id"]; ok {
+		traceID = v.String()
+	} else if ctx != nil {
+		if t, ok := TraceFromContext(ctx); ok {
+			traceID = t.TraceID
+		}
+	}
+
+	// Built-in attrs
+	message := ""
+	if v, ok := h.replaceBuiltin(slog.MessageKey, slog.StringValue(r.Message)); ok {
+		message = v.String()
+	}
+
+	level := ""
+	if v, ok := h.replaceBuiltin(slog.LevelKey, slog.AnyValue(r.Level)); ok {
+		if l, isLevel := v.Any().(slog.Level); isLevel {
+			level = l.String()
+		} else {
+			level = v.String()
+		}
 	}
 
-	// Colorize
-	coloredPath := h.colorize(path, ColorPath)
-	coloredStatus := h.colorize(status, ColorStatus)
-	coloredRequestID := h.colorize(requestID, ColorRequestID)
-	coloredMessage := h.colorize(r.Message, ColorMessage)
-	coloredLogType := h.colorize(logType, ColorLogType)
+	// A zero time is omitted, the colon is kept with the time so the line
+	// still reads cleanly without it.
+	ts := ""
+	if !r.Time.IsZero() {
+		if v, ok := h.replaceBuiltin(slog.TimeKey, slog.TimeValue(r.Time)); ok {
+			if v.Kind() == slog.KindTime {
+				ts = v.Time().Format(TextTimeFormat) + ":"
+			} else {
+				ts = " " + v.String() + ":"
+			}
+		}
+	}
 
-	// Pad method and level to keep lines pretty
-	paddedMethod := fmt.Sprintf("%-7s", method)
-	coloredMethod := h.colorize(paddedMethod, ColorMethod)
-	paddedLevel := fmt.Sprintf("%-5s", r.Level.String())
-	coloredLevel := h.colorize(paddedLevel, ColorLevel)
+	// Pad level to keep lines pretty
+	levelPrefix := ""
+	if level != "" {
+		paddedLevel := fmt.Sprintf("%-5s", level)
+		levelPrefix = "[" + h.colorize(paddedLevel, ColorLevel) + "]"
+	}
 
 	// Source
 	source := ""
@@ -253,59 +364,73 @@ func (h *TextHandler) Handle(_ context.Context, r slog.Record) error {
 		frames := runtime.CallersFrames([]uintptr{r.PC})
 		frame, _ := frames.Next()
 		if frame.File != "" {
-			// Line is padded to hundreds place. After line 999 lines will start to offset
-			// from anything under 1000.
-			line := h.colorize(fmt.Sprintf("%-3s", strconv.Itoa(frame.Line)), ColorLine)
-			source = fmt.Sprintf(" (%s:%s)", filepath.Base(frame.File), line)
+			src := &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+			if v, ok := h.replaceBuiltin(slog.SourceKey, slog.AnyValue(src)); ok {
+				if src, isSource := v.Any().(*slog.Source); isSource {
+					// Line is padded to hundreds place. After line 999 lines will start to offset
+					// from anything under 1000.
+					line := h.colorize(fmt.Sprintf("%-3s", strconv.Itoa(src.Line)), ColorLine)
+					source = fmt.Sprintf(" (%s:%s)", filepath.Base(src.File), line)
+				} else {
+					source = fmt.Sprintf(" (%s)", v.String())
+				}
+			}
 		}
 	}
 
 	// RequestID prefix
 	reqIDPrefix := ""
 	if requestID != "" {
-		reqIDPrefix = fmt.Sprintf(" [%s]", coloredRequestID)
+		reqIDPrefix = fmt.Sprintf(" [%s]", h.colorize(requestID, ColorRequestID))
 	}
 
-	// Bytes suffix
-	bytesSuffix := bytes
-	if bytes != "" {
-		bytesSuffix = bytes + "B"
+	// Trace prefix
+	tracePrefix := ""
+	if traceID != "" {
+		tracePrefix = fmt.Sprintf(" [%s]", h.colorize(shortTraceID(traceID), ColorTraceID))
 	}
 
-	// Bytes and duration section
-	bytesTime := ""
-	if bytesSuffix != "" || duration != "" {
-		bytesTime = fmt.Sprintf(" [%5s %10s] ", bytesSuffix, duration)
+	// Build line, the body is laid out by the log type renderer
+	entry := LogEntry{LogType: logType, Message: message, Attrs: values, h: h}
+	line := fmt.Sprintf("%s%s%s%s%s | %s", levelPrefix, reqIDPrefix, tracePrefix, ts, source, renderer.Render(entry))
+
+	inline, block := h.formatFields(fields)
+	if inline != "" {
+		line += " " + inline
 	}
+	line += "\n"
+	if renderer.Block != nil {
+		line += renderer.Block(entry)
+	}
+	line += block
 
-	// Build line
-	ts := r.Time.Format(TextTimeFormat)
-	var line string
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	_, err := io.WriteString(h.out, line)
+	return err
+}
 
-	// check log type and create log line accordingly
-	switch logType {
-	case "http_request":
-		displayType := h.colorize("HTTP Request", ColorLogType)
-		line = fmt.Sprintf("[%s]%s%s:%s | %s | %s %s %s %s%s %s",
-			coloredLevel, reqIDPrefix, ts, source, displayType,
-			coloredStatus, coloredMethod, coloredPath, remote,
-			bytesTime, coloredMessage,
-		)
-	default:
-		typePrefix := ""
-		if logType != "" {
-			typePrefix = " | " + coloredLogType
+// lastPredefined returns the value of the last top level field with key.
+func lastPredefined(fields []field, key string) (slog.Value, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].predefinedKey() == key {
+			return fields[i].attr.Value, true
 		}
-		line = fmt.Sprintf("[%s]%s:%s%s | %s", coloredLevel, ts, source, typePrefix, coloredMessage)
 	}
+	return slog.Value{}, false
+}
 
-	if len(fields) > 0 {
-		line += " " + strings.Join(fields, " ")
+// replaceBuiltin runs one of the built-in record keys (time, level, msg, source)
+// through ReplaceAttr. It returns false if ReplaceAttr dropped the attr.
+func (h *TextHandler) replaceBuiltin(key string, v slog.Value) (slog.Value, bool) {
+	if h.replaceAttr == nil {
+		return v, true
 	}
-	line += "\n"
-
-	fmt.Fprint(h.out, line)
-	return nil
+	a := h.replaceAttr(nil, slog.Attr{Key: key, Value: v})
+	if a.Key == "" {
+		return slog.Value{}, false
+	}
+	return a.Value.Resolve(), true
 }
 
 // WithAttrs is the slog-human implementation of slog.Handler interface
@@ -314,25 +439,12 @@ func (h *TextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		return h
 	}
 
-	newH := &TextHandler{
-		out:       h.out,
-		level:     h.level,
-		addSource: h.addSource,
-		noColor:   h.noColor,
-		group:     h.group,
-		mx:        sync.Mutex{},
-	}
-	if h.group != "" {
-		prefixed := make([]slog.Attr, len(attrs))
-		for i, a := range attrs {
-			prefixed[i] = slog.Attr{
-				Key:   h.group + "." + a.Key,
-				Value: a.Value,
-			}
-		}
-		newH.attrs = append(h.attrs, prefixed...)
-	} else {
-		newH.attrs = append(h.attrs, attrs...)
+	newH := h.clone()
+
+	// ReplaceAttr is applied once here, the same way slog's built-in handlers
+	// pre-format attrs added with WithAttrs.
+	for _, a := range attrs {
+		newH.attrs = h.appendAttr(newH.attrs, h.groups, a)
 	}
 
 	return newH
@@ -344,23 +456,32 @@ func (h *TextHandler) WithGroup(name string) slog.Handler {
 		return h
 	}
 
-	newH := &TextHandler{
-		out:       h.out,
-		level:     h.level,
-		addSource: h.addSource,
-		attrs:     h.attrs,
-		mx:        sync.Mutex{},
-	}
-
-	if h.group == "" {
-		newH.group = name
-	} else {
-		newH.group = h.group + "." + name
-	}
+	newH := h.clone()
+	newH.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
 
 	return newH
 }
 
+// clone returns a copy of the handler used by WithAttrs and WithGroup.
+// The mutex is shared so clones writing to the same writer never interleave lines.
+func (h *TextHandler) clone() *TextHandler {
+	return &TextHandler{
+		mx:           h.mx,
+		out:          h.out,
+		level:        h.level,
+		addSource:    h.addSource,
+		attrs:        h.attrs[:len(h.attrs):len(h.attrs)],
+		groups:       h.groups,
+		noColor:      h.noColor,
+		groupStyle:   h.groupStyle,
+		palette:      h.palette,
+		prettyBodies: h.prettyBodies,
+		slowQuery:    h.slowQuery,
+		extractors:   h.extractors,
+		replaceAttr:  h.replaceAttr,
+	}
+}
+
 // setMultiHandlers is an internal function used to create a new multiHandler containing the provided handlers
 func setMultiHandlers(handlers ...slog.Handler) slog.Handler {
 	return &multiHandler{handlers: handlers}
@@ -376,16 +497,19 @@ func (m *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return false
 }
 
-// Handle is the slog-human multiHandler implementation of slog.Handler interface
+// Handle is the slog-human multiHandler implementation of slog.Handler interface.
+// Each handler receives its own clone of the record and every handler is called
+// even if an earlier one fails. All errors are joined and returned.
 func (m *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
 	for _, h := range m.handlers {
 		if h.Enabled(ctx, r.Level) {
-			if err := h.Handle(ctx, r); err != nil {
-				return err
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // WithAttrs is the slog-human multiHandler implementation of slog.Handler interface
@@ -2,15 +2,22 @@ package sloghuman_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"testing/slogtest"
 	"time"
 
 	logger "github.com/tmstorm/slog-human"
@@ -139,6 +146,128 @@ func TestNewLoggerMultiHandler_TextLogger_HTTPRequestFormat(t *testing.T) {
 	}
 }
 
+func TestRegisterLogType(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	logger.RegisterLogType("job_run", logger.LogTypeRenderer{
+		Keys: []string{"job", "status"},
+		Render: func(e logger.LogEntry) string {
+			return "Job | " + e.Attr("job") + " " + e.Colorize(e.Attr("status"), logger.ColorStatus) + " " + e.Message
+		},
+	})
+	t.Cleanup(func() { logger.UnregisterLogType("job_run") })
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:   logger.LoggerTypeText,
+		Writer: &buf,
+		Opts: &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		},
+	})
+
+	l.With(slog.String("job", "cleanup")).Info("done",
+		slog.String("log_type", "job_run"),
+		slog.String("request_id", "abc123"),
+		slog.String("status", "200"),
+		slog.Int("removed", 3),
+	)
+	a.Equal("[INFO ] [abc123] | Job | cleanup 200 done removed=3\n", buf.String())
+
+	buf.Reset()
+	l.Info("unknown", slog.String("log_type", "cron"), slog.String("status", "200"))
+	a.Equal("[INFO ] | cron | unknown status=200\n", buf.String())
+
+	buf.Reset()
+	logger.UnregisterLogType("job_run")
+	l.Info("done", slog.String("log_type", "job_run"), slog.String("status", "200"))
+	a.Equal("[INFO ] | job_run | done status=200\n", buf.String())
+}
+
+func TestSQLQueryLogType(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:     logger.LoggerTypeText,
+		Writer:   &buf,
+		TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeNever},
+	})
+
+	l.Info("user lookup",
+		slog.String("log_type", "sql_query"),
+		slog.String("db", "primary"),
+		slog.String("query", "select id,  name\n\tfrom users\n where name = 'a  b'"),
+		slog.Any("args", []any{"a  b"}),
+		slog.Int("rows", 1),
+		slog.Duration("duration", 2*time.Millisecond),
+	)
+	a.Contains(buf.String(), "| SQL Query | primary [   1 rows        2ms] select id, name from users where name = 'a  b' args=[a  b] user lookup\n")
+	a.NotContains(buf.String(), "SLOW")
+
+	buf.Reset()
+	l.Info("", slog.String("log_type", "sql_query"), slog.Duration("duration", logger.DefaultSlowQuery+time.Second))
+	a.Contains(buf.String(), "SLOW")
+
+	buf.Reset()
+	l = logger.NewLoggerMultiHandler(logger.Handler{
+		Type:     logger.LoggerTypeText,
+		Writer:   &buf,
+		TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeNever, SlowQuery: -1},
+	})
+	l.Info("", slog.String("log_type", "sql_query"), slog.Duration("duration", time.Hour))
+	a.NotContains(buf.String(), "SLOW")
+
+	buf.Reset()
+	palette := logger.Dracula
+	l = logger.NewLoggerMultiHandler(logger.Handler{
+		Type:     logger.LoggerTypeText,
+		Writer:   &buf,
+		TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeAlways, Palette: &palette},
+	})
+	l.Info("", slog.String("log_type", "sql_query"), slog.String("query", "SELECT 1"))
+	a.Contains(buf.String(), palette.LogType+"SELECT"+palette.Reset+" 1")
+}
+
+func TestGRPCCallLogType(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+	palette := logger.Dracula
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:     logger.LoggerTypeText,
+		Writer:   &buf,
+		TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeAlways, Palette: &palette},
+	})
+
+	call := func(code string) string {
+		buf.Reset()
+		l.Info("",
+			slog.String("log_type", "grpc_call"),
+			slog.String("service", "greeter.v1.Greeter"),
+			slog.String("method", "SayHello"),
+			slog.String("code", code),
+			slog.String("peer", "127.0.0.1:5000"),
+			slog.Int("sent", 1),
+			slog.Int("received", 1),
+			slog.Duration("duration", time.Millisecond),
+		)
+		return buf.String()
+	}
+
+	out := call("OK")
+	a.Contains(out, palette.Status2xx+"OK"+palette.Reset)
+	a.Contains(out, palette.Path+"/greeter.v1.Greeter/SayHello"+palette.Reset+" 127.0.0.1:5000 [  1/1          1ms]")
+	a.Contains(call("NotFound"), palette.Status4xx+"NotFound"+palette.Reset)
+	a.Contains(call("Unavailable"), palette.Status5xx+"Unavailable"+palette.Reset)
+	a.Contains(call("13"), palette.Status5xx+"Internal"+palette.Reset)
+}
+
 func TestNewLoggerMultiHandler_NullOpts(t *testing.T) {
 	var buf bytes.Buffer
 	a := assert.New(t)
@@ -200,6 +329,490 @@ func TestNewLoggerMultiHandler_HTTPWriter(t *testing.T) {
 	a.Equal(bufOut, string(body))
 }
 
+func TestHTTPWriter_Batching(t *testing.T) {
+	a := assert.New(t)
+
+	type request struct {
+		contentType     string
+		contentEncoding string
+		body            string
+	}
+	var (
+		mx       sync.Mutex
+		requests []request
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			a.NoError(err)
+			body = zr
+		}
+		b, _ := io.ReadAll(body)
+
+		mx.Lock()
+		requests = append(requests, request{r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"), string(b)})
+		mx.Unlock()
+	}))
+	defer s.Close()
+
+	received := func() []request {
+		mx.Lock()
+		defer mx.Unlock()
+		return slices.Clone(requests)
+	}
+
+	// Size limits flush synchronously, the remainder is flushed by the interval
+	w := logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+		BatchSize:     3,
+		FlushInterval: 50 * time.Millisecond,
+	})
+	for i := range 7 {
+		fmt.Fprintf(w, "{\"n\":%d}\n", i)
+	}
+	got := received()
+	a.Len(got, 2)
+	a.Equal("{\"n\":0}\n{\"n\":1}\n{\"n\":2}\n", got[0].body)
+	a.Equal("application/x-ndjson", got[0].contentType)
+	a.Eventually(func() bool { return len(received()) == 3 }, time.Second, 10*time.Millisecond)
+	a.Equal("{\"n\":6}\n", received()[2].body)
+	a.NoError(w.Close())
+
+	// Byte limits never split a line and gzip compresses the array
+	mx.Lock()
+	requests = nil
+	mx.Unlock()
+	w = logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+		BatchBytes:  20,
+		BatchFormat: logger.BatchFormatJSONArray,
+		Gzip:        true,
+	})
+	_, _ = w.Write([]byte("{\"a\":1}\n{\"b\":2}\n"))
+	_, _ = w.Write([]byte("{\"c\":3}\n"))
+	got = received()
+	a.Len(got, 1)
+	a.Equal(`[{"a":1},{"b":2}]`, got[0].body)
+	a.Equal("application/json", got[0].contentType)
+	a.Equal("gzip", got[0].contentEncoding)
+
+	// The rest of the batch is sent by Close
+	a.NoError(w.Close())
+	a.Len(received(), 2)
+
+	a.Equal("JSONArray", logger.BatchFormatJSONArray.String())
+	a.Equal("BatchFormat(5)", logger.BatchFormat(5).String())
+}
+
+func TestHTTPWriter_Overflow(t *testing.T) {
+	tests := []struct {
+		policy logger.OverflowPolicy
+		want   []string
+	}{
+		{policy: logger.OverflowDropNewest, want: []string{"0\n", "1\n"}},
+		{policy: logger.OverflowDropOldest, want: []string{"0\n", "3\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			a := assert.New(t)
+			var (
+				mx       sync.Mutex
+				received []string
+				started  = make(chan struct{}, 1)
+				release  = make(chan struct{})
+			)
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				mx.Lock()
+				received = append(received, string(b))
+				mx.Unlock()
+				started <- struct{}{}
+				<-release
+			}))
+			defer s.Close()
+
+			w := logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+				Async:     true,
+				QueueSize: 1,
+				Workers:   1,
+				Overflow:  tt.policy,
+			})
+
+			// The only worker is busy with the first line, the queue holds one more
+			_, _ = w.Write([]byte("0\n"))
+			<-started
+			for _, line := range []string{"1\n", "2\n", "3\n"} {
+				_, _ = w.Write([]byte(line))
+			}
+			a.Equal(uint64(2), w.Dropped())
+
+			close(release)
+			<-started
+			mx.Lock()
+			defer mx.Unlock()
+			a.Equal(tt.want, received)
+		})
+	}
+
+	t.Run(logger.OverflowBlock.String(), func(t *testing.T) {
+		a := assert.New(t)
+		var (
+			mx       sync.Mutex
+			received []string
+			started  = make(chan struct{}, 3)
+			release  = make(chan struct{})
+		)
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			mx.Lock()
+			received = append(received, string(b))
+			mx.Unlock()
+			started <- struct{}{}
+			<-release
+		}))
+		defer s.Close()
+
+		w := logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+			Async:     true,
+			QueueSize: 1,
+			Workers:   1,
+			Overflow:  logger.OverflowBlock,
+		})
+
+		_, _ = w.Write([]byte("0\n"))
+		<-started
+		_, _ = w.Write([]byte("1\n"))
+
+		// The queue is full so the write waits, Flush must not wait behind it
+		written := make(chan struct{})
+		go func() {
+			_, _ = w.Write([]byte("2\n"))
+			close(written)
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		a.ErrorIs(w.Flush(ctx), context.DeadlineExceeded)
+
+		close(release)
+		<-written
+		a.NoError(w.Close())
+		a.Zero(w.Dropped())
+
+		mx.Lock()
+		defer mx.Unlock()
+		a.Equal([]string{"0\n", "1\n", "2\n"}, received)
+		a.Equal("OverflowPolicy(9)", logger.OverflowPolicy(9).String())
+	})
+}
+
+func TestHTTPWriter_Retry(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		mx       sync.Mutex
+		statuses []int
+		attempts int
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		defer mx.Unlock()
+		status := http.StatusOK
+		if attempts < len(statuses) {
+			status = statuses[attempts]
+		}
+		attempts++
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+	}))
+	defer s.Close()
+
+	reset := func(s ...int) {
+		mx.Lock()
+		defer mx.Unlock()
+		statuses, attempts = s, 0
+	}
+	count := func() int {
+		mx.Lock()
+		defer mx.Unlock()
+		return attempts
+	}
+
+	w := logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+		MaxRetries:   3,
+		RetryBackoff: time.Millisecond,
+	})
+
+	// 429 and 5xx are retried until the request succeeds
+	reset(http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusBadGateway)
+	_, _ = w.Write([]byte("line\n"))
+	a.Equal(4, count())
+	a.Equal(uint64(0), w.Dropped())
+
+	// Other 4xx are not
+	reset(http.StatusBadRequest)
+	_, _ = w.Write([]byte("line\n"))
+	a.Equal(1, count())
+	a.Equal(uint64(1), w.Dropped())
+
+	// Retries run out
+	reset(500, 500, 500, 500, 500)
+	_, _ = w.Write([]byte("line\n"))
+	a.Equal(4, count())
+	a.Equal(uint64(2), w.Dropped())
+
+	// The breaker opens after two failures and lets a single request through after the cooldown
+	w = logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+		BreakerThreshold: 2,
+		BreakerCooldown:  50 * time.Millisecond,
+	})
+	reset(500, 500, 500)
+	for range 4 {
+		_, _ = w.Write([]byte("line\n"))
+	}
+	a.Equal(2, count())
+	a.Equal(uint64(4), w.Dropped())
+
+	time.Sleep(60 * time.Millisecond)
+	_, _ = w.Write([]byte("line\n"))
+	_, _ = w.Write([]byte("line\n"))
+	a.Equal(3, count())
+
+	time.Sleep(60 * time.Millisecond)
+	_, _ = w.Write([]byte("line\n"))
+	_, _ = w.Write([]byte("line\n"))
+	a.Equal(5, count())
+	a.Equal(uint64(6), w.Dropped())
+
+	// A write is not held up while another one backs off
+	failing := make(chan struct{}, 1)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b, _ := io.ReadAll(r.Body); string(b) == "slow\n" {
+			select {
+			case failing <- struct{}{}:
+			default:
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer slow.Close()
+
+	w = logger.NewHTTPWriterWithOptions(slow.URL, &logger.HTTPWriterOptions{
+		MaxRetries:      3,
+		RetryBackoff:    time.Second,
+		MaxRetryBackoff: 2 * time.Second,
+		CloseTimeout:    10 * time.Millisecond,
+	})
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("slow\n"))
+		close(written)
+	}()
+	<-failing
+
+	start := time.Now()
+	_, _ = w.Write([]byte("fast\n"))
+	a.Less(time.Since(start), 500*time.Millisecond)
+
+	// Close stops the backoff
+	a.ErrorIs(w.Close(), context.DeadlineExceeded)
+	<-written
+}
+
+func TestHTTPWriter_FlushAndShutdown(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		mx       sync.Mutex
+		received []string
+		release  = make(chan struct{})
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		b, _ := io.ReadAll(r.Body)
+		mx.Lock()
+		received = append(received, string(b))
+		mx.Unlock()
+	}))
+	defer s.Close()
+
+	body := func() string {
+		mx.Lock()
+		defer mx.Unlock()
+		return strings.Join(received, "")
+	}
+
+	w := logger.NewAsyncHTTPWriter(s.URL)
+	_, _ = w.Write([]byte("full\npartial"))
+
+	// Flush gives up at the deadline while the server is stuck
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	a.ErrorIs(w.Flush(ctx), context.DeadlineExceeded)
+
+	close(release)
+	a.NoError(w.Flush(context.Background()))
+	a.Equal("full\npartial\n", body())
+
+	a.NoError(w.Close())
+	_, err := w.Write([]byte("late\n"))
+	a.ErrorIs(err, os.ErrClosed)
+	a.NoError(w.Close())
+
+	// Shutdown flushes the writers of every logger
+	mx.Lock()
+	received = nil
+	mx.Unlock()
+	batched := logger.NewHTTPWriterWithOptions(s.URL, &logger.HTTPWriterOptions{
+		Async:         true,
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+	})
+	l := logger.NewLoggerMultiHandler(logger.Handler{Type: logger.LoggerTypeJSON, Writer: batched})
+	l.Info("one")
+	l.Info("two")
+	a.Empty(body())
+
+	a.NoError(logger.Shutdown(context.Background()))
+	a.Contains(body(), `"msg":"one"`)
+	a.Contains(body(), `"msg":"two"`)
+	_, err = batched.Write([]byte("late\n"))
+	a.ErrorIs(err, os.ErrClosed)
+
+	// Writers are closed within the deadline of Shutdown even when the flush fails
+	stop := make(chan struct{})
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-stop }))
+	defer stuck.Close()
+	defer close(stop)
+
+	slow := logger.NewAsyncHTTPWriter(stuck.URL)
+	l = logger.NewLoggerMultiHandler(logger.Handler{Type: logger.LoggerTypeJSON, Writer: slow})
+	l.Info("stuck")
+
+	// A synchronous writer backing off is stopped as well
+	failing := make(chan struct{}, 1)
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case failing <- struct{}{}:
+		default:
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	retrying := logger.NewHTTPWriterWithOptions(unavailable.URL, &logger.HTTPWriterOptions{
+		MaxRetries:      3,
+		RetryBackoff:    time.Second,
+		MaxRetryBackoff: 2 * time.Second,
+	})
+	l = logger.NewLoggerMultiHandler(logger.Handler{Type: logger.LoggerTypeJSON, Writer: retrying})
+	logged := make(chan struct{})
+	go func() {
+		l.Info("retrying")
+		close(logged)
+	}()
+	<-failing
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	a.ErrorIs(logger.Shutdown(ctx), context.DeadlineExceeded)
+	<-logged
+	a.Less(time.Since(start), time.Second)
+	for _, w := range []*logger.HTTPWriter{slow, retrying} {
+		_, err = w.Write([]byte("late\n"))
+		a.ErrorIs(err, os.ErrClosed)
+	}
+}
+
+func TestHTTPWriter_Spool(t *testing.T) {
+	a := assert.New(t)
+
+	var (
+		mx       sync.Mutex
+		down     = true
+		received []string
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		defer mx.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		received = append(received, string(b))
+	}))
+	defer s.Close()
+
+	dir := t.TempDir()
+	opts := &logger.HTTPWriterOptions{
+		SpoolDir:          dir,
+		SpoolSegmentBytes: 16,
+		BatchSize:         2,
+		FlushInterval:     time.Millisecond,
+		RetryBackoff:      time.Millisecond,
+		MaxRetryBackoff:   5 * time.Millisecond,
+		CloseTimeout:      20 * time.Millisecond,
+	}
+	segments := func() []string {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+		return files
+	}
+
+	// Lines are kept on disk while the endpoint is down and survive the writer
+	w := logger.NewHTTPWriterWithOptions(s.URL, opts)
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, _ = w.Write([]byte(line))
+	}
+	a.ErrorIs(w.Close(), context.DeadlineExceeded)
+	a.NotEmpty(segments())
+	a.Equal(uint64(0), w.Dropped())
+
+	// The next writer sends them in order once the endpoint is back
+	mx.Lock()
+	down = false
+	mx.Unlock()
+	w = logger.NewHTTPWriterWithOptions(s.URL, opts)
+	_, _ = w.Write([]byte("six\n"))
+	a.NoError(w.Flush(context.Background()))
+	a.NoError(w.Close())
+
+	mx.Lock()
+	a.Equal("one\ntwo\nthree\nfour\nfive\nsix\n", strings.Join(received, ""))
+	mx.Unlock()
+	a.Empty(segments())
+
+	// A segment that cannot be read is kept until it can be, here a symlink loop
+	mx.Lock()
+	received = nil
+	mx.Unlock()
+	seg := filepath.Join(dir, "00000000000000000099.ndjson")
+	a.NoError(os.Symlink(seg, seg))
+	w = logger.NewHTTPWriterWithOptions(s.URL, opts)
+	time.Sleep(20 * time.Millisecond)
+	a.NoError(os.WriteFile(seg+".new", []byte("seven\n"), 0o600))
+	a.NoError(os.Rename(seg+".new", seg))
+	a.NoError(w.Flush(context.Background()))
+	a.NoError(w.Close())
+
+	mx.Lock()
+	a.Equal("seven\n", strings.Join(received, ""))
+	mx.Unlock()
+	a.Empty(segments())
+
+	// Lines that do not fit are dropped
+	opts.SpoolMaxBytes = 8
+	w = logger.NewHTTPWriterWithOptions(s.URL, opts)
+	_, _ = w.Write([]byte("fits\n"))
+	_, _ = w.Write([]byte("too big\n"))
+	a.Equal(uint64(1), w.Dropped())
+	a.NoError(w.Close())
+}
+
 func TestNewLoggerMultiHandler_MultipleHandlers(t *testing.T) {
 	var buf bytes.Buffer
 	a := assert.New(t)
@@ -356,6 +969,597 @@ func TestNewLoggerMultiHandler_WithGroup(t *testing.T) {
 	a.Contains(out, `"user":{"id":"123","location":"home"}`)
 }
 
+func TestNewLoggerMultiHandler_NestedGroups(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:   logger.LoggerTypeText,
+		Writer: &buf,
+	})
+
+	l.WithGroup("req").Info("",
+		slog.Group("user", slog.Int("id", 42), slog.Group("empty")),
+		slog.Group("", slog.String("inline", "yes")),
+		slog.String("method", "GET"),
+	)
+
+	out := buf.String()
+	a.Contains(out, "req.user.id=42")
+	a.Contains(out, "req.inline=yes")
+	a.Contains(out, "req.method=GET")
+	a.NotContains(out, "empty")
+	a.NotContains(out, "=[")
+}
+
+func TestNewLoggerMultiHandler_GroupStyleBlock(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:     logger.LoggerTypeText,
+		Writer:   &buf,
+		TextOpts: &logger.TextOptions{GroupStyle: logger.GroupStyleBlock},
+	})
+
+	l.With(slog.String("foo", "bar")).Info("block groups",
+		slog.Group("req", slog.String("method", "GET"), slog.Group("user", slog.Int("id", 42))),
+	)
+
+	out := buf.String()
+	a.Contains(out, "foo=bar\n")
+	a.Contains(out, "\n  req:\n    method=GET\n    user:\n      id=42\n")
+}
+
+func TestNewLoggerMultiHandler_ReplaceAttr(t *testing.T) {
+	var textBuf, jsonBuf bytes.Buffer
+	a := assert.New(t)
+
+	var textGroups [][]string
+	replace := func(groups []string, attr slog.Attr) slog.Attr {
+		switch attr.Key {
+		case "status":
+			return slog.Attr{}
+		case "method":
+			attr.Key = "http_method"
+		case "password":
+			attr.Value = slog.StringValue("REDACTED")
+		case slog.MessageKey:
+			attr.Value = slog.StringValue("replaced message")
+		case slog.SourceKey:
+			return slog.Attr{}
+		}
+		if attr.Key == "id" {
+			textGroups = append(textGroups, groups)
+		}
+		return attr
+	}
+
+	l := logger.NewLoggerMultiHandler(
+		[]logger.Handler{
+			{
+				Type:   logger.LoggerTypeText,
+				Writer: &textBuf,
+				Opts: &slog.HandlerOptions{
+					Level:       slog.LevelInfo,
+					AddSource:   true,
+					ReplaceAttr: replace,
+				},
+			},
+			{
+				Type:   logger.LoggerTypeJSON,
+				Writer: &jsonBuf,
+				Opts: &slog.HandlerOptions{
+					Level:       slog.LevelInfo,
+					ReplaceAttr: replace,
+				},
+			},
+		}...)
+
+	l.WithGroup("user").With(slog.String("id", "123")).Info("original message",
+		slog.String("status", "201"),
+		slog.String("method", "GET"),
+		slog.String("password", "hunter2"),
+		slog.Group("session", slog.String("id", "abc")),
+	)
+
+	text := textBuf.String()
+	a.Contains(text, "replaced message")
+	a.NotContains(text, "original message")
+	a.NotContains(text, "201")
+	a.Contains(text, "http_method=GET")
+	a.Contains(text, "REDACTED")
+	a.NotContains(text, "hunter2")
+	a.NotContains(text, ".go:")
+
+	js := jsonBuf.String()
+	a.NotContains(js, "201")
+	a.Contains(js, `"http_method":"GET"`)
+	a.Contains(js, `"password":"REDACTED"`)
+
+	a.Contains(textGroups, []string{"user"})
+	a.Contains(textGroups, []string{"user", "session"})
+}
+
+func TestTextHandler_SlogTest(t *testing.T) {
+	var buf bytes.Buffer
+
+	slogtest.Run(t, func(*testing.T) slog.Handler {
+		buf.Reset()
+		return logger.NewLoggerMultiHandler(logger.Handler{
+			Type:   logger.LoggerTypeText,
+			Writer: &buf,
+			Opts:   &slog.HandlerOptions{AddSource: true},
+		}).Handler()
+	}, func(t *testing.T) map[string]any {
+		return parseTextLine(t, buf.String())
+	})
+}
+
+func TestMultiHandler_SlogTest(t *testing.T) {
+	var textBuf, jsonBuf bytes.Buffer
+	newHandler := func(*testing.T) slog.Handler {
+		textBuf.Reset()
+		jsonBuf.Reset()
+		return logger.NewLoggerMultiHandler(
+			[]logger.Handler{
+				{
+					Type:   logger.LoggerTypeText,
+					Writer: &textBuf,
+					Opts:   &slog.HandlerOptions{AddSource: true},
+				},
+				{
+					Type:   logger.LoggerTypeJSON,
+					Writer: &jsonBuf,
+					Opts:   &slog.HandlerOptions{AddSource: true},
+				},
+			}...).Handler()
+	}
+
+	t.Run("text", func(t *testing.T) {
+		slogtest.Run(t, newHandler, func(t *testing.T) map[string]any {
+			return parseTextLine(t, textBuf.String())
+		})
+	})
+
+	t.Run("json", func(t *testing.T) {
+		slogtest.Run(t, newHandler, func(t *testing.T) map[string]any {
+			m := map[string]any{}
+			if err := json.Unmarshal(jsonBuf.Bytes(), &m); err != nil {
+				t.Fatal(err)
+			}
+			return m
+		})
+	})
+}
+
+// ansiEscape matches the color codes added by the TextHandler
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// parseTextLine parses a single line written by the TextHandler into a map
+// as expected by testing/slogtest. Dotted keys are nested into maps per group.
+//
+// The line is read as: [LEVEL] time: (source) | log_type | message key=value ...
+func parseTextLine(t *testing.T, line string) map[string]any {
+	t.Helper()
+	line = strings.TrimSuffix(ansiEscape.ReplaceAllString(line, ""), "\n")
+	if strings.Contains(line, "\n") {
+		t.Fatalf("expected a single line, got %q", line)
+	}
+
+	m := map[string]any{}
+
+	// Level
+	if strings.HasPrefix(line, "[") {
+		end := strings.Index(line, "]")
+		if end < 0 {
+			t.Fatalf("unterminated level in %q", line)
+		}
+		m[slog.LevelKey] = strings.TrimSpace(line[1:end])
+		line = line[end+1:]
+	}
+
+	// Header and body are split on the last separator
+	sep := strings.LastIndex(line, " | ")
+	if sep < 0 {
+		t.Fatalf("missing message separator in %q", line)
+	}
+	header, body := line[:sep], line[sep+len(" | "):]
+
+	// Everything after the first separator in the header is the log_type
+	header, _, _ = strings.Cut(header, " | ")
+
+	// Source
+	if i := strings.LastIndex(header, " ("); i >= 0 && strings.HasSuffix(header, ")") {
+		m[slog.SourceKey] = header[i+2 : len(header)-1]
+		header = header[:i]
+	}
+
+	// Time
+	if ts := strings.TrimSuffix(strings.TrimSpace(header), ":"); ts != "" {
+		m[slog.TimeKey] = ts
+	}
+
+	// Message and attrs
+	msg, attrs, _ := strings.Cut(body, " ")
+	m[slog.MessageKey] = msg
+	for _, kv := range strings.Fields(attrs) {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			t.Fatalf("malformed attr %q in %q", kv, line)
+		}
+		path := strings.Split(key, ".")
+		group := m
+		for _, g := range path[:len(path)-1] {
+			sub, ok := group[g].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				group[g] = sub
+			}
+			group = sub
+		}
+		group[path[len(path)-1]] = value
+	}
+
+	return m
+}
+
+func TestHTTPLogger(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{Type: logger.LoggerTypeJSON, Writer: &buf})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = io.Copy(w, strings.NewReader("hello"))
+		a.NoError(http.NewResponseController(w).Flush())
+		_, _, err := http.NewResponseController(w).Hijack()
+		a.ErrorIs(err, http.ErrNotSupported)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(logger.RequestIDHeader, "abc123")
+	rec := httptest.NewRecorder()
+	logger.HTTPLogger(l)(mux).ServeHTTP(rec, req)
+
+	a.Equal(http.StatusTeapot, rec.Code)
+	a.True(rec.Flushed)
+
+	var entry map[string]any
+	a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+	a.Equal("WARN", entry["level"])
+	a.Equal("http_request", entry["log_type"])
+	a.Equal("GET", entry["method"])
+	a.Equal("/users/42", entry["path"])
+	a.Equal("GET /users/{id}", entry["pattern"])
+	a.Equal(float64(http.StatusTeapot), entry["status"])
+	a.Equal(float64(5), entry["bytes"])
+	a.Equal("abc123", entry["request_id"])
+}
+
+func TestMiddlewareOptions(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{Type: logger.LoggerTypeJSON, Writer: &buf})
+	opts := &logger.MiddlewareOptions{
+		SkipPaths:       []string{"/health"},
+		SkipPathRegexps: []*regexp.Regexp{regexp.MustCompile(`^/static/`)},
+		StatusLevel: func(status int) slog.Level {
+			if status >= 500 {
+				return slog.LevelError
+			}
+			return slog.LevelInfo
+		},
+		LatencyWarn:   time.Millisecond,
+		Query:         true,
+		UserAgent:     true,
+		Referer:       true,
+		Host:          true,
+		Protocol:      true,
+		ContentLength: true,
+	}
+
+	delay := time.Duration(0)
+	h := logger.HTTPLoggerWithOptions(l, opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	serve := func(path string) map[string]any {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("body"))
+		req.Header.Set("User-Agent", "test-agent")
+		req.Header.Set("Referer", "http://example.com")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if buf.Len() == 0 {
+			return nil
+		}
+		var entry map[string]any
+		a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+		return entry
+	}
+
+	a.Nil(serve("/health"))
+	a.Nil(serve("/static/app.js"))
+
+	entry := serve("/users?page=2")
+	a.Equal("INFO", entry["level"])
+	a.Equal("page=2", entry["query"])
+	a.Equal("test-agent", entry["user_agent"])
+	a.Equal("http://example.com", entry["referer"])
+	a.Equal("example.com", entry["host"])
+	a.Equal("HTTP/1.1", entry["protocol"])
+	a.Equal(float64(4), entry["content_length"])
+
+	delay = 5 * time.Millisecond
+	a.Equal("WARN", serve("/users")["level"])
+
+	a.Equal(slog.LevelWarn, (*logger.MiddlewareOptions)(nil).Level(http.StatusNotFound, 0))
+	a.Equal(slog.LevelError, (&logger.MiddlewareOptions{LatencyError: time.Second}).Level(http.StatusOK, 2*time.Second))
+}
+
+func TestMiddlewareOptions_Capture(t *testing.T) {
+	var jsonBuf, textBuf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(
+		logger.Handler{Type: logger.LoggerTypeJSON, Writer: &jsonBuf},
+		logger.Handler{
+			Type:     logger.LoggerTypeText,
+			Writer:   &textBuf,
+			TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeNever, PrettyBodies: true},
+		},
+	)
+	opts := &logger.MiddlewareOptions{
+		RequestHeaders:  []string{"Authorization", "content-type"},
+		ResponseHeaders: []string{"*"},
+		RequestBody:     true,
+		ResponseBody:    true,
+		MaxBodySize:     16,
+	}
+
+	h := logger.HTTPLoggerWithOptions(l, opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		a.Equal(`{"name":"gopher"}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"gopher"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	a.NoError(json.Unmarshal(jsonBuf.Bytes(), &entry))
+	a.Equal(map[string]any{"Authorization": logger.RedactedValue, "Content-Type": "application/json"}, entry["request_headers"])
+	a.Equal(map[string]any{"Content-Type": "application/json", "Set-Cookie": logger.RedactedValue}, entry["response_headers"])
+	a.Equal(`{"name":"gopher"... (truncated)`, entry["request_body"])
+	a.Equal(`{"id":1}`, entry["response_body"])
+	a.NotContains(jsonBuf.String(), "secret")
+
+	out := textBuf.String()
+	a.Contains(out, "\n  response_body:\n    {\n      \"id\": 1\n    }\n")
+	a.Contains(out, "\n  request_body:\n    {\"name\":\"gopher\"... (truncated)\n")
+	a.NotContains(out, "response_body=")
+
+	// Bodies of content types outside the allow list are not logged
+	jsonBuf.Reset()
+	h = logger.HTTPLoggerWithOptions(l, &logger.MiddlewareOptions{ResponseBody: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte{0x89, 'P', 'N', 'G'})
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/logo.png", nil))
+	a.NotContains(jsonBuf.String(), "response_body")
+}
+
+func TestHTTPRecoverer(t *testing.T) {
+	var jsonBuf, textBuf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(
+		logger.Handler{Type: logger.LoggerTypeJSON, Writer: &jsonBuf},
+		logger.Handler{Type: logger.LoggerTypeText, Writer: &textBuf, TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeNever}},
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	for name, h := range map[string]http.Handler{
+		"with logger":    logger.HTTPLogger(l)(logger.HTTPRecoverer(l)(handler)),
+		"recoverer only": logger.HTTPRecoverer(l)(handler),
+	} {
+		t.Run(name, func(t *testing.T) {
+			jsonBuf.Reset()
+			textBuf.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+			req.Header.Set(logger.RequestIDHeader, "abc123")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			a.Equal(http.StatusInternalServerError, rec.Code)
+
+			lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+			a.Len(lines, 1)
+			var entry map[string]any
+			a.NoError(json.Unmarshal([]byte(lines[0]), &entry))
+			a.Equal("ERROR", entry["level"])
+			a.Equal("http_request", entry["log_type"])
+			a.Equal(float64(http.StatusInternalServerError), entry["status"])
+			a.Equal("abc123", entry["request_id"])
+			a.Equal("boom", entry["panic"])
+
+			stack := entry["stack"].(string)
+			a.True(strings.HasPrefix(stack, "github.com/tmstorm/slog-human_test.TestHTTPRecoverer.func1 ("), stack)
+			a.NotContains(stack, "runtime/debug.Stack")
+			a.NotContains(stack, "+0x")
+
+			out := textBuf.String()
+			a.Contains(out, "[abc123]")
+			a.Contains(out, "\n  panic: boom\n  stack:\n    github.com/tmstorm/slog-human_test.TestHTTPRecoverer.func1 (")
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	a.Same(slog.Default(), logger.FromContext(context.Background()))
+
+	l := logger.NewLoggerMultiHandler(logger.Handler{Type: logger.LoggerTypeJSON, Writer: &buf})
+	h := logger.HTTPLogger(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("loading user")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(logger.RequestIDHeader, "abc123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	a.Len(lines, 2)
+	var entry map[string]any
+	a.NoError(json.Unmarshal([]byte(lines[0]), &entry))
+	a.Equal("loading user", entry["msg"])
+	a.Equal("abc123", entry["request_id"])
+	a.Equal("GET", entry["method"])
+	a.Equal("/users/42", entry["path"])
+}
+
+func TestTextHandler_ContextExtractors(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	type tenantKey struct{}
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:   logger.LoggerTypeText,
+		Writer: &buf,
+		TextOpts: &logger.TextOptions{
+			ColorMode: logger.ColorModeNever,
+			ContextExtractors: []logger.ContextExtractor{
+				logger.RequestIDExtractor,
+				logger.ContextValue(tenantKey{}, "tenant_id"),
+			},
+		},
+	})
+
+	ctx := logger.ContextWithRequestID(context.Background(), "abc123")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	l.WithGroup("job").InfoContext(ctx, "started", slog.Int("id", 7))
+	a.Contains(buf.String(), "[INFO ] [abc123] ")
+	a.Contains(buf.String(), "job.id=7 tenant_id=acme\n")
+
+	buf.Reset()
+	l.InfoContext(ctx, "override", slog.String("request_id", "def456"), slog.String("tenant_id", "other"))
+	a.Contains(buf.String(), "[def456]")
+	a.Contains(buf.String(), "tenant_id=other\n")
+	a.NotContains(buf.String(), "acme")
+
+	buf.Reset()
+	l.Info("no context")
+	a.NotContains(buf.String(), "tenant_id")
+}
+
+func TestTraceparent(t *testing.T) {
+	var textBuf, jsonBuf bytes.Buffer
+	a := assert.New(t)
+
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tc, err := logger.ParseTraceparent(header)
+	a.NoError(err)
+	a.Equal("4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	a.Equal("00f067aa0ba902b7", tc.SpanID)
+	a.True(tc.Sampled())
+	a.Equal(header, tc.String())
+
+	_, err = logger.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	a.NoError(err)
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err = logger.ParseTraceparent(invalid)
+		a.ErrorIs(err, logger.ErrInvalidTraceparent, invalid)
+	}
+
+	l := logger.NewLoggerMultiHandler(
+		[]logger.Handler{
+			{
+				Type:     logger.LoggerTypeText,
+				Writer:   &textBuf,
+				TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeNever},
+			},
+			{
+				Type:   logger.LoggerTypeJSON,
+				Writer: &jsonBuf,
+			},
+		}...)
+
+	var got logger.TraceContext
+	handler := logger.HTTPTraceparent(logger.HTTPLogger(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = logger.TraceFromContext(r.Context())
+		outgoing := http.Header{}
+		logger.SetTraceparent(r.Context(), outgoing)
+		a.Equal(got.String(), outgoing.Get(logger.TraceparentHeader))
+	})))
+
+	// An incoming trace is continued with a new span
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(logger.TraceparentHeader, header)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	a.Equal(tc.TraceID, got.TraceID)
+	a.NotEqual(tc.SpanID, got.SpanID)
+	a.Contains(textBuf.String(), "[INFO ] [4bf92f35] ")
+
+	var record map[string]any
+	a.NoError(json.Unmarshal(jsonBuf.Bytes(), &record))
+	a.Equal(tc.TraceID, record["trace_id"])
+	a.Equal(got.SpanID, record["span_id"])
+
+	// A missing or invalid header starts a new trace
+	textBuf.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(logger.TraceparentHeader, "garbage")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	a.Regexp(regexp.MustCompile(`^[0-9a-f]{32}$`), got.TraceID)
+	a.Regexp(regexp.MustCompile(`^[0-9a-f]{16}$`), got.SpanID)
+	a.Contains(textBuf.String(), "["+got.TraceID[:8]+"]")
+
+	// Records without a trace are unchanged
+	textBuf.Reset()
+	jsonBuf.Reset()
+	l.Info("no trace")
+	a.NotContains(jsonBuf.String(), "trace_id")
+	a.NotRegexp(regexp.MustCompile(`\[[0-9a-f]{8}\]`), textBuf.String())
+
+	// The trace stays at the top level of records logged in a group
+	jsonBuf.Reset()
+	ctx := logger.ContextWithTrace(context.Background(), tc)
+	l.WithGroup("req").With("path", "/users").InfoContext(ctx, "grouped", "status", 200)
+	record = nil
+	a.NoError(json.Unmarshal(jsonBuf.Bytes(), &record))
+	a.Equal(tc.TraceID, record["trace_id"])
+	a.Equal(tc.SpanID, record["span_id"])
+	a.Equal(map[string]any{"path": "/users", "status": float64(200)}, record["req"])
+
+	// A trace_id added with With is not repeated
+	jsonBuf.Reset()
+	l.With("trace_id", "custom").InfoContext(ctx, "with trace")
+	a.Equal(1, strings.Count(jsonBuf.String(), `"trace_id"`))
+	a.Contains(jsonBuf.String(), `"trace_id":"custom"`)
+}
+
 func TestSetLoggerAdapter_SetSlogDefault(t *testing.T) {
 	var buf bytes.Buffer
 	a := assert.New(t)
@@ -433,6 +1637,98 @@ func TestLoggerInterface_DebugLevels(t *testing.T) {
 	a.Contains(out, "debug context test")
 }
 
+func TestLoggerInterface_LevelVar(t *testing.T) {
+	var buf bytes.Buffer
+	a := assert.New(t)
+
+	lvl := &slog.LevelVar{}
+	l := logger.NewLoggerMultiHandler(logger.Handler{
+		Type:   logger.LoggerTypeText,
+		Writer: &buf,
+		Opts:   &slog.HandlerOptions{Level: lvl},
+	}).WithGroup("g").With("foo", "bar")
+
+	l.Debug("before change")
+	lvl.Set(slog.LevelDebug)
+	l.Debug("after change")
+
+	out := buf.String()
+	a.NotContains(out, "before change")
+	a.Contains(out, "after change")
+}
+
+func TestLevels_SetAndReset(t *testing.T) {
+	var textBuf, jsonBuf bytes.Buffer
+	a := assert.New(t)
+	t.Cleanup(logger.Levels().Reset)
+
+	l := logger.NewLoggerMultiHandler(
+		[]logger.Handler{
+			{
+				Type:   logger.LoggerTypeText,
+				Writer: &textBuf,
+			},
+			{
+				Type:   logger.LoggerTypeJSON,
+				Writer: &jsonBuf,
+			},
+		}...)
+
+	l.Debug("hidden debug")
+
+	logger.Levels().Set(slog.LevelDebug)
+	level, ok := logger.Levels().Level()
+	a.True(ok)
+	a.Equal(slog.LevelDebug, level)
+	l.Debug("visible debug")
+
+	logger.Levels().Reset()
+	_, ok = logger.Levels().Level()
+	a.False(ok)
+	l.Debug("hidden again")
+
+	for _, out := range []string{textBuf.String(), jsonBuf.String()} {
+		a.NotContains(out, "hidden debug")
+		a.Contains(out, "visible debug")
+		a.NotContains(out, "hidden again")
+	}
+}
+
+func TestColorize_PerHandlerPalette(t *testing.T) {
+	var nordBuf, oneDarkBuf, defaultBuf bytes.Buffer
+	a := assert.New(t)
+
+	l := logger.NewLoggerMultiHandler(
+		[]logger.Handler{
+			{
+				Type:     logger.LoggerTypeText,
+				Writer:   &nordBuf,
+				TextOpts: &logger.TextOptions{Palette: &logger.Nord, ColorMode: logger.ColorModeAlways},
+			},
+			{
+				Type:     logger.LoggerTypeText,
+				Writer:   &oneDarkBuf,
+				TextOpts: &logger.TextOptions{Palette: &logger.OneDark, ColorMode: logger.ColorModeAlways},
+			},
+			{
+				Type:     logger.LoggerTypeText,
+				Writer:   &defaultBuf,
+				TextOpts: &logger.TextOptions{ColorMode: logger.ColorModeAlways},
+			},
+		}...)
+
+	// Changing the default after creation must not affect existing handlers
+	old := logger.Colors
+	logger.Colors = logger.GruvboxDark
+	t.Cleanup(func() { logger.Colors = old })
+
+	l.Info("", slog.String("path", "/health"), slog.String("log_type", "http_request"))
+
+	a.Contains(nordBuf.String(), logger.Nord.Path+"/health")
+	a.Contains(oneDarkBuf.String(), logger.OneDark.Path+"/health")
+	a.Contains(defaultBuf.String(), old.Path+"/health")
+}
+
 func TestColorize_DefaultColors_AppliedToAllCases(t *testing.T) {
 	var buf bytes.Buffer
 	a := assert.New(t)
@@ -444,6 +1740,9 @@ func TestColorize_DefaultColors_AppliedToAllCases(t *testing.T) {
 			Level:     slog.LevelDebug,
 			AddSource: true,
 		},
+		TextOpts: &logger.TextOptions{
+			ColorMode: logger.ColorModeAlways,
+		},
 	})
 
 	c := logger.Colors
@@ -516,6 +1815,217 @@ func TestColorize_DefaultColors_AppliedToAllCases(t *testing.T) {
 	a.NotContains(out, "\033[0m\033")
 }
 
+func TestTheme_ColorDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth logger.ColorDepth
+		env   map[string]string
+		want  string
+	}{
+		{name: "truecolor", depth: logger.ColorDepthTrueColor, want: "\033[38;2;80;250;123m"},
+		{name: "256", depth: logger.ColorDepth256, want: "\033[38;5;84m"},
+		{name: "16", depth: logger.ColorDepth16, want: "\033[92m"},
+		{name: "auto COLORTERM", env: map[string]string{"COLORTERM": "truecolor", "TERM": "xterm"}, want: "\033[38;2;80;250;123m"},
+		{name: "auto TERM 256color", env: map[string]string{"COLORTERM": "", "TERM": "xterm-256color"}, want: "\033[38;5;84m"},
+		{name: "auto TERM xterm", env: map[string]string{"COLORTERM": "", "TERM": "xterm"}, want: "\033[92m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			t.Setenv("WT_SESSION", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			l := logger.NewLoggerMultiHandler(logger.Handler{
+				Type:   logger.LoggerTypeText,
+				Writer: &buf,
+				TextOpts: &logger.TextOptions{
+					Theme:      &logger.DraculaTheme,
+					ColorMode:  logger.ColorModeAlways,
+					ColorDepth: tt.depth,
+				},
+			})
+			l.Info("", slog.String("method", "GET"), slog.String("log_type", "http_request"))
+
+			assert.Contains(t, buf.String(), tt.want+"GET")
+		})
+	}
+}
+
+func TestStyle_Escape(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("", logger.Style{}.Escape(logger.ColorDepth256))
+	a.Equal("\033[1;4m", logger.Style{Bold: true, Underline: true}.Escape(logger.ColorDepth16))
+	a.Equal("\033[38;5;16m", logger.Style{Fg: logger.RGB(0, 0, 0)}.Escape(logger.ColorDepth256))
+	a.Equal("\033[38;5;244m", logger.Style{Fg: logger.Hex(0x808080)}.Escape(logger.ColorDepth256))
+	a.Equal("\033[91m", logger.Style{Fg: logger.Hex(0xff5555)}.Escape(logger.ColorDepth16))
+	a.Equal(logger.Dracula, logger.DraculaTheme.Palette(logger.ColorDepth256))
+}
+
+func TestParseTheme_Formats(t *testing.T) {
+	a := assert.New(t)
+
+	files := map[logger.ThemeFormat]string{
+		logger.ThemeFormatJSON: `{"extends": "nord", "level_error": "bold #ff5555", "Path": "underline cyan"}`,
+		logger.ThemeFormatTOML: `# custom theme
+[theme]
+extends = "nord"
+level_error = "bold #ff5555" # errors stand out
+Path = 'underline cyan'
+`,
+		logger.ThemeFormatYAML: `---
+extends: nord
+level_error: "bold #ff5555"
+Path: underline cyan # paths
+`,
+	}
+
+	want := logger.NordTheme
+	want.LevelERROR = logger.Style{Fg: logger.Hex(0xff5555), Bold: true}
+	want.Path = logger.Style{Fg: logger.RGB(0, 170, 170), Underline: true}
+
+	for format, data := range files {
+		theme, err := logger.ParseTheme([]byte(data), format)
+		a.NoError(err, format.String())
+		a.Equal(want, theme, format.String())
+	}
+
+	_, err := logger.ParseTheme([]byte(`{"not_a_key": "red"}`), logger.ThemeFormatJSON)
+	a.ErrorContains(err, "not_a_key")
+
+	_, err = logger.ParseTheme([]byte(`{"path": "sparkly"}`), logger.ThemeFormatJSON)
+	a.ErrorContains(err, "sparkly")
+
+	// Only the flat layout is supported
+	_, err = logger.ParseTheme([]byte("[colors]\npath = \"cyan\"\n"), logger.ThemeFormatTOML)
+	a.ErrorContains(err, "line 1: unsupported table [colors]")
+
+	_, err = logger.ParseTheme([]byte("colors:\n  path: cyan\n"), logger.ThemeFormatYAML)
+	a.ErrorContains(err, "line 1: colors has no value")
+
+	a.Equal("ThemeFormat(9)", logger.ThemeFormat(9).String())
+}
+
+func TestThemeEnv(t *testing.T) {
+	a := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "theme.toml")
+	a.NoError(os.WriteFile(file, []byte(`level_info = "#010203"`), 0o600))
+
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{name: "built-in", env: "Gruvbox-Dark", want: logger.GruvboxDarkTheme.LevelINFO.Escape(logger.ColorDepthTrueColor)},
+		{name: "file", env: file, want: "\033[38;2;1;2;3m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			t.Setenv(logger.ThemeEnv, tt.env)
+
+			l := logger.NewLoggerMultiHandler(logger.Handler{
+				Type:   logger.LoggerTypeText,
+				Writer: &buf,
+				TextOpts: &logger.TextOptions{
+					ColorMode:  logger.ColorModeAlways,
+					ColorDepth: logger.ColorDepthTrueColor,
+				},
+			})
+			l.Info("theme env")
+
+			assert.Contains(t, buf.String(), tt.want+"INFO")
+		})
+	}
+
+	// The environment is not read when the handler sets a theme
+	t.Setenv(logger.ThemeEnv, "no-such-theme")
+	out := captureStdout(func() {
+		logger.NewLoggerMultiHandler(logger.Handler{
+			Type:     logger.LoggerTypeText,
+			Writer:   io.Discard,
+			TextOpts: &logger.TextOptions{Theme: &logger.NordTheme},
+		})
+	})
+	a.NotContains(out, logger.ThemeEnv)
+}
+
+func TestLookupTheme_AccessibleThemes(t *testing.T) {
+	a := assert.New(t)
+
+	for _, name := range []string{"solarized-light", "gruvbox_light", "GitHub Light", "colorblind", "monochrome"} {
+		_, ok := logger.LookupTheme(name)
+		a.True(ok, name)
+	}
+
+	// Monochrome only uses styles
+	mono := logger.MonochromeTheme.Palette(logger.ColorDepthTrueColor)
+	for _, code := range []string{mono.Status2xx, mono.Status5xx, mono.LevelINFO, mono.LevelERROR} {
+		a.NotContains(code, "38;")
+	}
+	a.NotEqual(mono.Status2xx, mono.Status5xx)
+	a.NotEqual(mono.LevelINFO, mono.LevelERROR)
+
+	// Colorblind errors are told apart by style as well as color
+	cb := logger.ColorblindTheme
+	a.True(cb.Status5xx.Bold && !cb.Status2xx.Bold)
+	a.True(cb.LevelERROR.Bold && !cb.LevelINFO.Bold)
+}
+
+func TestColorMode(t *testing.T) {
+	envs := []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR", "CLICOLOR_FORCE", "TERM"}
+	tests := []struct {
+		name  string
+		mode  logger.ColorMode
+		env   map[string]string
+		color bool
+	}{
+		{name: "auto non-terminal", mode: logger.ColorModeAuto, color: false},
+		{name: "always", mode: logger.ColorModeAlways, env: map[string]string{"NO_COLOR": "1"}, color: true},
+		{name: "never", mode: logger.ColorModeNever, env: map[string]string{"FORCE_COLOR": "1"}, color: false},
+		{name: "FORCE_COLOR", env: map[string]string{"FORCE_COLOR": "1"}, color: true},
+		{name: "FORCE_COLOR=0", env: map[string]string{"FORCE_COLOR": "0", "CLICOLOR_FORCE": "1"}, color: false},
+		{name: "CLICOLOR_FORCE", env: map[string]string{"CLICOLOR_FORCE": "1"}, color: true},
+		{name: "NO_COLOR beats FORCE_COLOR", env: map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, color: false},
+		{name: "TERM=dumb", env: map[string]string{"TERM": "dumb"}, color: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			for _, k := range envs {
+				t.Setenv(k, "")
+				os.Unsetenv(k)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			l := logger.NewLoggerMultiHandler(logger.Handler{
+				Type:     logger.LoggerTypeText,
+				Writer:   &buf,
+				TextOpts: &logger.TextOptions{ColorMode: tt.mode},
+			})
+			l.Info("color mode")
+
+			assert.Equal(t, tt.color, strings.Contains(buf.String(), "\033["))
+		})
+	}
+}
+
+func TestColorMode_String(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("Never", logger.ColorModeNever.String())
+	a.Equal("ColorMode(7)", logger.ColorMode(7).String())
+	a.Equal("ColorDepth(-1)", logger.ColorDepth(-1).String())
+}
+
 func TestNoColor(t *testing.T) {
 	var buf bytes.Buffer
 	os.Setenv("NO_COLOR", "")
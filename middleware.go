@@ -0,0 +1,158 @@
+package sloghuman
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader is the request header HTTPLogger reads the request id from.
+const RequestIDHeader = "X-Request-Id"
+
+// HTTPLogger is net/http middleware that logs every request as an http_request
+// record. It has no dependencies outside the standard library. When the request
+// is routed by an http.ServeMux the matched pattern is logged as pattern.
+func HTTPLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return HTTPLoggerWithOptions(logger, &MiddlewareOptions{RoutePattern: true})
+}
+
+// HTTPLoggerWithOptions is HTTPLogger configured by opts.
+func HTTPLoggerWithOptions(logger *slog.Logger, opts *MiddlewareOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := r.Header.Get(RequestIDHeader)
+			ctx, requestPanic := ContextWithPanic(r.Context())
+			ctx = ContextWithRequestID(ctx, requestID)
+			ctx = WithLogger(ctx, RequestLogger(logger, RequestInfo{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				RequestID: requestID,
+			}))
+			r = r.WithContext(ctx)
+			reqBody := opts.CaptureRequestBody(r)
+			ww := &responseWriter{ResponseWriter: w, body: opts.CaptureResponseBody()}
+			t1 := time.Now()
+
+			defer func() {
+				t2 := time.Now()
+				info := NewRequestInfo(r)
+				info.Status = ww.Status()
+				info.Bytes = ww.bytes
+				info.Duration = t2.Sub(t1)
+				info.RequestID = requestID
+				info.Pattern = r.Pattern
+				info.ResponseHeader = ww.Header()
+				info.RequestBody = reqBody
+				info.ResponseBody = ww.body
+				info.Panic = requestPanic.Value
+				info.Stack = requestPanic.Stack
+				opts.Log(r.Context(), logger, info)
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// responseWriter captures the status and bytes written to an http.ResponseWriter.
+// The optional interfaces are always implemented and forwarded to the wrapped
+// writer, returning http.ErrNotSupported when it does not support them.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	body        *BodyBuffer
+}
+
+var (
+	_ http.Flusher  = (*responseWriter)(nil)
+	_ http.Hijacker = (*responseWriter)(nil)
+	_ http.Pusher   = (*responseWriter)(nil)
+	_ io.ReaderFrom = (*responseWriter)(nil)
+)
+
+// Status returns the status code written. Handlers that never write a status
+// respond with 200.
+func (w *responseWriter) Status() int {
+	if !w.wroteHeader {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	// Informational headers are followed by the final status, except switching protocols.
+	if !w.wroteHeader && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	if w.body != nil {
+		_, _ = w.body.Write(b[:n])
+	}
+	return n, err
+}
+
+// ReadFrom lets io.Copy use the wrapped writers ReadFrom, such as sendfile.
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.body != nil {
+		src = io.TeeReader(src, w.body)
+	}
+
+	var (
+		n   int64
+		err error
+	)
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		// Hide ReadFrom from io.Copy so it does not call back into this method.
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, src)
+	}
+	w.bytes += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
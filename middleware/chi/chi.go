@@ -1,46 +1,98 @@
 package middleware
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
 	"time"
 
+	sloghuman "github.com/tmstorm/slog-human"
+
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 func ChiLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return ChiLoggerWithOptions(logger, nil)
+}
+
+func ChiLoggerWithOptions(logger *slog.Logger, opts *sloghuman.MiddlewareOptions) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			requestID := middleware.GetReqID(r.Context())
+			ctx, requestPanic := sloghuman.ContextWithPanic(r.Context())
+			ctx = sloghuman.ContextWithRequestID(ctx, requestID)
+			ctx = sloghuman.WithLogger(ctx, sloghuman.RequestLogger(logger, sloghuman.RequestInfo{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				RequestID: requestID,
+			}))
+			r = r.WithContext(ctx)
+			reqBody := opts.CaptureRequestBody(r)
+			respBody := opts.CaptureResponseBody()
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			if respBody != nil {
+				ww.Tee(respBody)
+			}
 			t1 := time.Now()
 
 			defer func() {
 				t2 := time.Now()
-				status := ww.Status()
-				bytes := ww.BytesWritten()
-				values := []any{
-					slog.String("log_type", "http_request"),
-					slog.String("method", r.Method),
-					slog.String("path", r.URL.Path),
-					slog.String("remote", r.RemoteAddr),
-					slog.Int("status", status),
-					slog.Int("bytes", bytes),
-					slog.Duration("duration", t2.Sub(t1)),
-					slog.String("request_id", middleware.GetReqID(r.Context())),
-				}
-				switch {
-				case status >= 200 && status <= 299:
-					logger.Info("", values...)
-				case status >= 300 && status <= 499:
-					logger.Warn("", values...)
-				case status >= 500 && status <= 599:
-					logger.Error("", values...)
-				default:
-					logger.Info("", values...)
+				info := sloghuman.NewRequestInfo(r)
+				info.Status = ww.Status()
+				info.Bytes = int64(ww.BytesWritten())
+				info.Duration = t2.Sub(t1)
+				info.RequestID = requestID
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					info.Pattern = rctx.RoutePattern()
 				}
+				info.ResponseHeader = ww.Header()
+				info.RequestBody = reqBody
+				info.ResponseBody = respBody
+				info.Panic = requestPanic.Value
+				info.Stack = requestPanic.Stack
+				opts.Log(r.Context(), logger, info)
 			}()
 
 			next.ServeHTTP(ww, r)
 		})
 	}
 }
+
+func ChiRecoverer(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t1 := time.Now()
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// ErrAbortHandler is used to abort a response and is handled by the server
+				if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(p)
+				}
+				stack := debug.Stack()
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+
+				info := sloghuman.NewRequestInfo(r)
+				info.Status = http.StatusInternalServerError
+				info.Duration = time.Since(t1)
+				info.RequestID = middleware.GetReqID(r.Context())
+				sloghuman.LogPanic(r.Context(), logger, info, p, stack)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
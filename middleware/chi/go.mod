@@ -2,4 +2,9 @@ module github.com/tmstorm/slog-human/middleware/chi
 
 go 1.24
 
-require github.com/go-chi/chi/v5 v5.2.3
+require (
+	github.com/go-chi/chi/v5 v5.2.3
+	github.com/tmstorm/slog-human v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/tmstorm/slog-human => ../..
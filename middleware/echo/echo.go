@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	sloghuman "github.com/tmstorm/slog-human"
+
+	"github.com/labstack/echo/v4"
+)
+
+func EchoLogger(logger *slog.Logger) echo.MiddlewareFunc {
+	return EchoLoggerWithOptions(logger, nil)
+}
+
+func EchoLoggerWithOptions(logger *slog.Logger, opts *sloghuman.MiddlewareOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if opts.Skip(c.Request().URL.Path) {
+				return next(c)
+			}
+
+			ctx, requestPanic := sloghuman.ContextWithPanic(c.Request().Context())
+			ctx = sloghuman.ContextWithRequestID(ctx, requestID(c))
+			ctx = sloghuman.WithLogger(ctx, sloghuman.RequestLogger(logger, sloghuman.RequestInfo{
+				Method:    c.Request().Method,
+				Path:      c.Request().URL.Path,
+				RequestID: requestID(c),
+			}))
+			c.SetRequest(c.Request().WithContext(ctx))
+			reqBody := opts.CaptureRequestBody(c.Request())
+			respBody := opts.CaptureResponseBody()
+			if respBody != nil {
+				c.Response().Writer = &bodyWriter{ResponseWriter: c.Response().Writer, body: respBody}
+			}
+			t1 := time.Now()
+
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so the status is known
+				c.Error(err)
+			}
+
+			t2 := time.Now()
+			req := c.Request()
+			res := c.Response()
+
+			info := sloghuman.NewRequestInfo(req)
+			info.Status = res.Status
+			info.Bytes = res.Size
+			info.Duration = t2.Sub(t1)
+			info.RequestID = requestID(c)
+			info.Pattern = c.Path()
+			info.ResponseHeader = res.Header()
+			info.RequestBody = reqBody
+			info.ResponseBody = respBody
+			info.Panic = requestPanic.Value
+			info.Stack = requestPanic.Stack
+			opts.Log(req.Context(), logger, info)
+
+			// The error has been handled
+			return nil
+		}
+	}
+}
+
+func EchoRecoverer(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			t1 := time.Now()
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// ErrAbortHandler is used to abort a response and is handled by the server
+				if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(p)
+				}
+				stack := debug.Stack()
+
+				if !c.Response().Committed {
+					err = c.NoContent(http.StatusInternalServerError)
+				}
+
+				req := c.Request()
+
+				info := sloghuman.NewRequestInfo(req)
+				info.Status = http.StatusInternalServerError
+				info.Duration = time.Since(t1)
+				info.RequestID = requestID(c)
+				info.Pattern = c.Path()
+				sloghuman.LogPanic(req.Context(), logger, info, p, stack)
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// requestID returns the id set by echo's RequestID middleware on the response
+// header, or the id sent by the client.
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// bodyWriter tees the response body into body.
+type bodyWriter struct {
+	http.ResponseWriter
+	body *sloghuman.BodyBuffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	_, _ = w.body.Write(b[:n])
+	return n, err
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *bodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
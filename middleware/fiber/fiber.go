@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	sloghuman "github.com/tmstorm/slog-human"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func FiberLogger(logger *slog.Logger) fiber.Handler {
+	return FiberLoggerWithOptions(logger, nil)
+}
+
+func FiberLoggerWithOptions(logger *slog.Logger, opts *sloghuman.MiddlewareOptions) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if opts.Skip(c.Path()) {
+			return c.Next()
+		}
+
+		// Fiber's requestid middleware stores the id in the locals
+		requestID, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+		if requestID == "" {
+			requestID = utils.CopyString(c.GetRespHeader(fiber.HeaderXRequestID))
+		}
+
+		// Fiber reuses the memory of the strings it returns once the handler
+		// returns, so the ones kept by the request logger are copied
+		ctx, requestPanic := sloghuman.ContextWithPanic(c.UserContext())
+		ctx = sloghuman.ContextWithRequestID(ctx, requestID)
+		ctx = sloghuman.WithLogger(ctx, sloghuman.RequestLogger(logger, sloghuman.RequestInfo{
+			Method:    utils.CopyString(c.Method()),
+			Path:      utils.CopyString(c.Path()),
+			RequestID: requestID,
+		}))
+		c.SetUserContext(ctx)
+		t1 := time.Now()
+
+		if err := c.Next(); err != nil {
+			// Let the error handler write the response so the status is known
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		t2 := time.Now()
+
+		req := c.Request()
+		info := sloghuman.RequestInfo{
+			Method:        utils.CopyString(c.Method()),
+			Path:          utils.CopyString(c.Path()),
+			Remote:        c.Context().RemoteAddr().String(),
+			RequestID:     requestID,
+			Status:        c.Response().StatusCode(),
+			Bytes:         int64(len(c.Response().Body())),
+			Duration:      t2.Sub(t1),
+			Query:         string(req.URI().QueryString()),
+			UserAgent:     string(req.Header.UserAgent()),
+			Referer:       string(req.Header.Referer()),
+			Host:          utils.CopyString(c.Hostname()),
+			Protocol:      string(req.Header.Protocol()),
+			Pattern:       c.Route().Path,
+			ContentLength: int64(req.Header.ContentLength()),
+
+			RequestHeader:  http.Header(c.GetReqHeaders()),
+			ResponseHeader: http.Header(c.GetRespHeaders()),
+
+			Panic: requestPanic.Value,
+			Stack: requestPanic.Stack,
+		}
+
+		// Fiber buffers the bodies so they are copied instead of teed
+		if opts != nil && opts.RequestBody {
+			info.RequestBody = opts.NewBodyBuffer()
+			_, _ = info.RequestBody.Write(c.Body())
+		}
+		if info.ResponseBody = opts.CaptureResponseBody(); info.ResponseBody != nil {
+			_, _ = info.ResponseBody.Write(c.Response().Body())
+		}
+		opts.Log(c.UserContext(), logger, info)
+
+		// The error has been handled
+		return nil
+	}
+}
+
+func FiberRecoverer(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		t1 := time.Now()
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			stack := debug.Stack()
+
+			err = c.SendStatus(fiber.StatusInternalServerError)
+
+			requestID, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+			if requestID == "" {
+				requestID = utils.CopyString(c.GetRespHeader(fiber.HeaderXRequestID))
+			}
+
+			info := sloghuman.RequestInfo{
+				Method:    utils.CopyString(c.Method()),
+				Path:      utils.CopyString(c.Path()),
+				Remote:    c.Context().RemoteAddr().String(),
+				RequestID: requestID,
+				Status:    fiber.StatusInternalServerError,
+				Duration:  time.Since(t1),
+				Pattern:   c.Route().Path,
+			}
+			sloghuman.LogPanic(c.UserContext(), logger, info, p, stack)
+		}()
+
+		return c.Next()
+	}
+}
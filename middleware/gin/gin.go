@@ -1,44 +1,124 @@
 package middleware
 
 import (
+	"errors"
 	"log/slog"
 	"math"
+	"net/http"
+	"runtime/debug"
 	"time"
 
+	sloghuman "github.com/tmstorm/slog-human"
+
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 )
 
+// LoggerKey is the gin context key of the request logger set by GinLogger.
+const LoggerKey = "slog-human/logger"
+
+// GinFromContext returns the request logger set by GinLogger, or slog.Default()
+// if there is none.
+func GinFromContext(c *gin.Context) *slog.Logger {
+	if l, ok := c.Value(LoggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return sloghuman.FromContext(c.Request.Context())
+}
+
 func GinLogger(logger *slog.Logger) gin.HandlerFunc {
+	return GinLoggerWithOptions(logger, nil)
+}
+
+func GinLoggerWithOptions(logger *slog.Logger, opts *sloghuman.MiddlewareOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if opts.Skip(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		requestID := requestid.Get(c)
+		requestLogger := sloghuman.RequestLogger(logger, sloghuman.RequestInfo{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			RequestID: requestID,
+		})
+		c.Set(LoggerKey, requestLogger)
+
+		ctx, requestPanic := sloghuman.ContextWithPanic(c.Request.Context())
+		ctx = sloghuman.ContextWithRequestID(ctx, requestID)
+		ctx = sloghuman.WithLogger(ctx, requestLogger)
+		c.Request = c.Request.WithContext(ctx)
+		reqBody := opts.CaptureRequestBody(c.Request)
+		respBody := opts.CaptureResponseBody()
+		if respBody != nil {
+			c.Writer = &bodyWriter{ResponseWriter: c.Writer, body: respBody}
+		}
 		t1 := time.Now()
 
 		defer func() {
 			t2 := time.Now()
-			status := c.Writer.Status()
-			bytes := int(math.Max(float64(c.Writer.Size()), 0))
-			values := []any{
-				slog.String("log_type", "http_request"),
-				slog.String("method", c.Request.Method),
-				slog.String("path", c.Request.URL.Path),
-				slog.String("remote", c.Request.RemoteAddr),
-				slog.Int("status", status),
-				slog.Int("bytes", bytes),
-				slog.Duration("duration", t2.Sub(t1)),
-				slog.String("request_id", requestid.Get(c)),
+			info := sloghuman.NewRequestInfo(c.Request)
+			info.Status = c.Writer.Status()
+			info.Bytes = int64(math.Max(float64(c.Writer.Size()), 0))
+			info.Duration = t2.Sub(t1)
+			info.RequestID = requestID
+			info.Pattern = c.FullPath()
+			info.ResponseHeader = c.Writer.Header()
+			info.RequestBody = reqBody
+			info.ResponseBody = respBody
+			info.Panic = requestPanic.Value
+			info.Stack = requestPanic.Stack
+			opts.Log(c.Request.Context(), logger, info)
+		}()
+
+		c.Next()
+	}
+}
+
+func GinRecoverer(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t1 := time.Now()
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
 			}
-			switch {
-			case status >= 200 && status <= 299:
-				logger.Info("", values...)
-			case status >= 300 && status <= 499:
-				logger.Warn("", values...)
-			case status >= 500 && status <= 599:
-				logger.Error("", values...)
-			default:
-				logger.Info("", values...)
+			// ErrAbortHandler is used to abort a response and is handled by the server
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p)
 			}
+			stack := debug.Stack()
+
+			c.AbortWithStatus(http.StatusInternalServerError)
+
+			info := sloghuman.NewRequestInfo(c.Request)
+			info.Status = http.StatusInternalServerError
+			info.Duration = time.Since(t1)
+			info.RequestID = requestid.Get(c)
+			info.Pattern = c.FullPath()
+			sloghuman.LogPanic(c.Request.Context(), logger, info, p, stack)
 		}()
 
 		c.Next()
 	}
 }
+
+// bodyWriter tees the response body into body.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *sloghuman.BodyBuffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	_, _ = w.body.Write(b[:n])
+	return n, err
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	_, _ = w.body.Write([]byte(s[:n]))
+	return n, err
+}
@@ -5,6 +5,7 @@ go 1.24
 require (
 	github.com/gin-contrib/requestid v1.0.5
 	github.com/gin-gonic/gin v1.11.0
+	github.com/tmstorm/slog-human v0.0.0-00010101000000-000000000000
 )
 
 require (
@@ -41,3 +42,5 @@ require (
 	golang.org/x/tools v0.34.0 // indirect
 	google.golang.org/protobuf v1.36.9 // indirect
 )
+
+replace github.com/tmstorm/slog-human => ../..
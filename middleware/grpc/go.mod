@@ -0,0 +1,22 @@
+module github.com/tmstorm/slog-human/middleware/grpc
+
+go 1.25.0
+
+require (
+	github.com/stretchr/testify v1.11.1
+	github.com/tmstorm/slog-human v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.82.1
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/tmstorm/slog-human => ../..
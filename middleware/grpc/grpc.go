@@ -0,0 +1,349 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	sloghuman "github.com/tmstorm/slog-human"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDKey is the metadata key the request id is read from.
+const RequestIDKey = "x-request-id"
+
+// GRPCUnaryServerInterceptor logs every unary call handled by the server as a grpc_call record.
+func GRPCUnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		t1 := time.Now()
+		resp, err := handler(withCallLogger(ctx, logger, info.FullMethod), req)
+
+		sent := 0
+		if err == nil {
+			sent = 1
+		}
+		logCall(ctx, logger, call{
+			fullMethod: info.FullMethod,
+			peer:       peerFromContext(ctx),
+			requestID:  incomingRequestID(ctx),
+			duration:   time.Since(t1),
+			sent:       sent,
+			received:   1,
+			err:        err,
+		})
+		return resp, err
+	}
+}
+
+// GRPCStreamServerInterceptor logs every streaming call handled by the server as a
+// grpc_call record once the handler returns.
+func GRPCStreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		t1 := time.Now()
+		ctx := ss.Context()
+		ws := &serverStream{
+			ServerStream: ss,
+			ctx:          withCallLogger(ctx, logger, info.FullMethod),
+		}
+		err := handler(srv, ws)
+
+		logCall(ctx, logger, call{
+			fullMethod: info.FullMethod,
+			peer:       peerFromContext(ctx),
+			requestID:  incomingRequestID(ctx),
+			duration:   time.Since(t1),
+			sent:       ws.sent,
+			received:   ws.received,
+			err:        err,
+		})
+		return err
+	}
+}
+
+// GRPCUnaryClientInterceptor logs every unary call made by the client as a grpc_call record.
+func GRPCUnaryClientInterceptor(logger *slog.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		t1 := time.Now()
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+
+		received := 0
+		if err == nil {
+			received = 1
+		}
+		logCall(ctx, logger, call{
+			fullMethod: method,
+			peer:       peerAddr(&p, cc.Target()),
+			requestID:  outgoingRequestID(ctx),
+			duration:   time.Since(t1),
+			sent:       1,
+			received:   received,
+			err:        err,
+		})
+		return err
+	}
+}
+
+// GRPCStreamClientInterceptor logs every streaming call made by the client as a
+// grpc_call record once the stream has finished, either by receiving io.EOF, the
+// response of a client streaming call or an error, or by ctx being done.
+func GRPCStreamClientInterceptor(logger *slog.Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs := &clientStream{
+			logger:        logger,
+			ctx:           ctx,
+			start:         time.Now(),
+			target:        cc.Target(),
+			method:        method,
+			serverStreams: desc.ServerStreams,
+			done:          make(chan struct{}),
+		}
+		stream, err := streamer(ctx, desc, cc, method, append(opts, grpc.Peer(&cs.peer))...)
+		if err != nil {
+			cs.finish(err)
+			return nil, err
+		}
+		cs.ClientStream = stream
+
+		// Streams that are canceled or abandoned never see io.EOF
+		go func() {
+			select {
+			case <-ctx.Done():
+				cs.cancel(status.FromContextError(ctx.Err()).Err())
+			case <-cs.done:
+			}
+		}()
+		return cs, nil
+	}
+}
+
+// call holds the values logged for a single gRPC call.
+type call struct {
+	fullMethod string
+	peer       string
+	requestID  string
+	duration   time.Duration
+	sent       int
+	received   int
+	err        error
+}
+
+// logCall logs c at a level matching the severity of its status code.
+func logCall(ctx context.Context, logger *slog.Logger, c call) {
+	service, method := splitMethod(c.fullMethod)
+	st := status.Convert(c.err)
+
+	values := []slog.Attr{
+		slog.String("log_type", "grpc_call"),
+		slog.String("service", service),
+		slog.String("method", method),
+		slog.String("code", st.Code().String()),
+		slog.String("peer", c.peer),
+		slog.Duration("duration", c.duration),
+		slog.Int("sent", c.sent),
+		slog.Int("received", c.received),
+	}
+	if c.requestID != "" {
+		values = append(values, slog.String("request_id", c.requestID))
+	}
+	if c.err != nil {
+		values = append(values, slog.String("error", st.Message()))
+	}
+
+	logger.LogAttrs(ctx, codeLevel(st.Code()), "", values...)
+}
+
+// withCallLogger returns a copy of ctx holding the request id of the call and
+// logger with the request_id, service and method of the call.
+func withCallLogger(ctx context.Context, logger *slog.Logger, fullMethod string) context.Context {
+	requestID := incomingRequestID(ctx)
+	service, method := splitMethod(fullMethod)
+	attrs := make([]any, 0, 3)
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	attrs = append(attrs,
+		slog.String("service", service),
+		slog.String("method", method),
+	)
+	ctx = sloghuman.ContextWithRequestID(ctx, requestID)
+	return sloghuman.WithLogger(ctx, logger.With(attrs...))
+}
+
+// codeLevel returns the log level for a status code. Codes caused by the caller
+// are logged as warnings and codes caused by the server as errors.
+func codeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// splitMethod splits a full method name, /package.Service/Method, into the service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "", fullMethod
+	}
+	return service, method
+}
+
+// peerFromContext returns the address of the peer stored in ctx by the server.
+func peerFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return peerAddr(p, "")
+}
+
+// peerAddr returns the address of p, or fallback if it is not known.
+func peerAddr(p *peer.Peer, fallback string) string {
+	if p == nil || p.Addr == nil {
+		return fallback
+	}
+	return p.Addr.String()
+}
+
+// incomingRequestID returns the request id sent by the client.
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return firstValue(md, RequestIDKey)
+}
+
+// outgoingRequestID returns the request id the client is sending.
+func outgoingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return firstValue(md, RequestIDKey)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// serverStream counts the messages sent and received by a server stream.
+// Streams are not safe to use from multiple goroutines for the same direction
+// so the counters only need to be read once the handler returns.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	sent     int
+	received int
+}
+
+// Context returns the stream context holding the request logger.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+	}
+	return err
+}
+
+// clientStream counts the messages sent and received by a client stream and
+// logs the call when it finishes.
+type clientStream struct {
+	grpc.ClientStream
+	logger *slog.Logger
+	ctx    context.Context
+	start  time.Time
+	target string
+	method string
+	peer   peer.Peer
+
+	// serverStreams is false for client streaming calls, which finish with
+	// their single response instead of io.EOF.
+	serverStreams bool
+
+	mx       sync.Mutex
+	sent     int
+	received int
+	once     sync.Once
+	done     chan struct{}
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.mx.Lock()
+		s.sent++
+		s.mx.Unlock()
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.mx.Lock()
+		s.received++
+		s.mx.Unlock()
+		if !s.serverStreams {
+			s.finish(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+	return err
+}
+
+// finish logs the call once.
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() { s.log(err, peerAddr(&s.peer, s.target)) })
+}
+
+// cancel logs the call once when its context is done. gRPC records the peer
+// while it cancels the stream so the target is logged instead.
+func (s *clientStream) cancel(err error) {
+	s.once.Do(func() { s.log(err, s.target) })
+}
+
+func (s *clientStream) log(err error, peer string) {
+	close(s.done)
+
+	s.mx.Lock()
+	sent, received := s.sent, s.received
+	s.mx.Unlock()
+
+	logCall(s.ctx, s.logger, call{
+		fullMethod: s.method,
+		peer:       peer,
+		requestID:  outgoingRequestID(s.ctx),
+		duration:   time.Since(s.start),
+		sent:       sent,
+		received:   received,
+		err:        err,
+	})
+}
@@ -0,0 +1,200 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	middleware "github.com/tmstorm/slog-human/middleware/grpc"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoService is a hand written service so the tests do not need generated code.
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Say", Handler: say},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Collect", Handler: collect, ClientStreams: true},
+		{StreamName: "Wait", Handler: wait, ServerStreams: true},
+	},
+}
+
+func say(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req any) (any, error) { return req, nil }
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Echo/Say"}, handler)
+}
+
+// collect joins the messages sent by the client.
+func collect(_ any, stream grpc.ServerStream) error {
+	var words []string
+	for {
+		in := new(wrapperspb.StringValue)
+		err := stream.RecvMsg(in)
+		if err == io.EOF {
+			return stream.SendMsg(wrapperspb.String(strings.Join(words, " ")))
+		}
+		if err != nil {
+			return err
+		}
+		words = append(words, in.Value)
+	}
+}
+
+// wait blocks until the client goes away.
+func wait(_ any, stream grpc.ServerStream) error {
+	if err := stream.RecvMsg(new(wrapperspb.StringValue)); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return status.FromContextError(stream.Context().Err()).Err()
+}
+
+// syncBuffer is a bytes.Buffer safe to log to from the server and client goroutines.
+type syncBuffer struct {
+	mx  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON records logged so far.
+func (b *syncBuffer) records() []map[string]any {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	var out []map[string]any
+	for line := range strings.Lines(b.buf.String()) {
+		var r map[string]any
+		if json.Unmarshal([]byte(line), &r) == nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func newTestConn(t *testing.T) (*grpc.ClientConn, *syncBuffer, *syncBuffer) {
+	t.Helper()
+	var serverLogs, clientLogs syncBuffer
+	serverLogger := slog.New(slog.NewJSONHandler(&serverLogs, nil))
+	clientLogger := slog.New(slog.NewJSONHandler(&clientLogs, nil))
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(middleware.GRPCUnaryServerInterceptor(serverLogger)),
+		grpc.StreamInterceptor(middleware.GRPCStreamServerInterceptor(serverLogger)),
+	)
+	srv.RegisterService(&echoService, struct{}{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(middleware.GRPCUnaryClientInterceptor(clientLogger)),
+		grpc.WithStreamInterceptor(middleware.GRPCStreamClientInterceptor(clientLogger)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, &serverLogs, &clientLogs
+}
+
+func TestGRPCInterceptors_Unary(t *testing.T) {
+	a := assert.New(t)
+	conn, serverLogs, clientLogs := newTestConn(t)
+
+	out := new(wrapperspb.StringValue)
+	a.NoError(conn.Invoke(context.Background(), "/test.Echo/Say", wrapperspb.String("hi"), out))
+	a.Equal("hi", out.Value)
+
+	for _, logs := range []*syncBuffer{serverLogs, clientLogs} {
+		if !a.Eventually(func() bool { return len(logs.records()) == 1 }, time.Second, 10*time.Millisecond) {
+			continue
+		}
+		r := logs.records()[0]
+		a.Equal("grpc_call", r["log_type"])
+		a.Equal("test.Echo", r["service"])
+		a.Equal("Say", r["method"])
+		a.Equal("OK", r["code"])
+		a.EqualValues(1, r["sent"])
+		a.EqualValues(1, r["received"])
+	}
+}
+
+func TestGRPCInterceptors_ClientStream(t *testing.T) {
+	a := assert.New(t)
+	conn, serverLogs, clientLogs := newTestConn(t)
+
+	cs, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ClientStreams: true}, "/test.Echo/Collect")
+	a.NoError(err)
+	a.NoError(cs.SendMsg(wrapperspb.String("one")))
+	a.NoError(cs.SendMsg(wrapperspb.String("two")))
+	a.NoError(cs.CloseSend())
+
+	out := new(wrapperspb.StringValue)
+	a.NoError(cs.RecvMsg(out))
+	a.Equal("one two", out.Value)
+
+	// The call is logged with its response, the client never sees io.EOF
+	records := clientLogs.records()
+	if a.Len(records, 1) {
+		a.Equal("Collect", records[0]["method"])
+		a.Equal("OK", records[0]["code"])
+		a.EqualValues(2, records[0]["sent"])
+		a.EqualValues(1, records[0]["received"])
+	}
+
+	a.Eventually(func() bool { return len(serverLogs.records()) == 1 }, time.Second, 10*time.Millisecond)
+	a.EqualValues(2, serverLogs.records()[0]["received"])
+}
+
+func TestGRPCInterceptors_CanceledStream(t *testing.T) {
+	a := assert.New(t)
+	conn, serverLogs, clientLogs := newTestConn(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cs, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Echo/Wait")
+	a.NoError(err)
+	a.NoError(cs.SendMsg(wrapperspb.String("wait")))
+	a.NoError(cs.CloseSend())
+
+	// The stream is abandoned without reading it to the end
+	cancel()
+
+	for _, logs := range []*syncBuffer{serverLogs, clientLogs} {
+		if !a.Eventually(func() bool { return len(logs.records()) == 1 }, time.Second, 10*time.Millisecond) {
+			continue
+		}
+		r := logs.records()[0]
+		a.Equal("Wait", r["method"])
+		a.Equal("Canceled", r["code"])
+	}
+}
@@ -0,0 +1,230 @@
+package sloghuman
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+)
+
+type (
+	// MiddlewareOptions configures the request logging middlewares. The same
+	// options are accepted by the net/http, Chi, Gin, Echo and Fiber middlewares.
+	// A nil *MiddlewareOptions uses the defaults.
+	MiddlewareOptions struct {
+		// SkipPaths are request paths that are not logged, e.g. "/health".
+		SkipPaths []string
+
+		// SkipPathRegexps are patterns of request paths that are not logged.
+		SkipPathRegexps []*regexp.Regexp
+
+		// StatusLevel returns the level a response status is logged at.
+		// Defaults to DefaultStatusLevel.
+		StatusLevel func(status int) slog.Level
+
+		// LatencyWarn raises the level of requests taking longer than it to at least WARN.
+		// Zero disables it.
+		LatencyWarn time.Duration
+
+		// LatencyError raises the level of requests taking longer than it to ERROR.
+		// Zero disables it.
+		LatencyError time.Duration
+
+		// Query logs the raw query string as query.
+		Query bool
+
+		// UserAgent logs the User-Agent header as user_agent.
+		UserAgent bool
+
+		// Referer logs the Referer header as referer.
+		Referer bool
+
+		// Host logs the request host as host.
+		Host bool
+
+		// Protocol logs the request protocol, e.g. HTTP/1.1, as protocol.
+		Protocol bool
+
+		// RoutePattern logs the matched route pattern, e.g. /users/{id}, as pattern.
+		RoutePattern bool
+
+		// ContentLength logs the request content length as content_length.
+		ContentLength bool
+
+		// RequestHeaders are the request headers logged in the request_headers group.
+		// Use "*" to log every header.
+		RequestHeaders []string
+
+		// ResponseHeaders are the response headers logged in the response_headers group.
+		// Use "*" to log every header.
+		ResponseHeaders []string
+
+		// RedactHeaders are logged as RedactedValue. Defaults to DefaultRedactHeaders.
+		RedactHeaders []string
+
+		// RequestBody logs the request body, as read by the handler, as request_body.
+		RequestBody bool
+
+		// ResponseBody logs the response body as response_body.
+		ResponseBody bool
+
+		// MaxBodySize is the number of body bytes logged. Defaults to DefaultMaxBodySize.
+		MaxBodySize int
+
+		// BodyContentTypes are the content types of the bodies logged, e.g. text/*.
+		// Defaults to DefaultBodyContentTypes.
+		BodyContentTypes []string
+	}
+
+	// RequestInfo holds the values of a request logged by the middlewares.
+	RequestInfo struct {
+		Method        string
+		Path          string
+		Remote        string
+		RequestID     string
+		Status        int
+		Bytes         int64
+		Duration      time.Duration
+		Query         string
+		UserAgent     string
+		Referer       string
+		Host          string
+		Protocol      string
+		Pattern       string
+		ContentLength int64
+
+		RequestHeader  http.Header
+		ResponseHeader http.Header
+		RequestBody    *BodyBuffer
+		ResponseBody   *BodyBuffer
+
+		// Panic is the value recovered from the handler and Stack the stack
+		// from debug.Stack. The request is logged at ERROR when Panic is set.
+		Panic any
+		Stack []byte
+	}
+)
+
+// DefaultStatusLevel logs 5xx statuses at ERROR, 3xx and 4xx at WARN and
+// everything else at INFO.
+func DefaultStatusLevel(status int) slog.Level {
+	switch {
+	case status >= 200 && status <= 299:
+		return slog.LevelInfo
+	case status >= 300 && status <= 499:
+		return slog.LevelWarn
+	case status >= 500 && status <= 599:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewRequestInfo returns the RequestInfo of r. The response values, request id,
+// pattern and captured bodies are left for the middleware to set.
+func NewRequestInfo(r *http.Request) RequestInfo {
+	return RequestInfo{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Remote:        r.RemoteAddr,
+		Query:         r.URL.RawQuery,
+		UserAgent:     r.UserAgent(),
+		Referer:       r.Referer(),
+		Host:          r.Host,
+		Protocol:      r.Proto,
+		ContentLength: r.ContentLength,
+		RequestHeader: r.Header,
+	}
+}
+
+// Skip reports whether requests to path should not be logged.
+func (o *MiddlewareOptions) Skip(path string) bool {
+	if o == nil {
+		return false
+	}
+	if slices.Contains(o.SkipPaths, path) {
+		return true
+	}
+	for _, re := range o.SkipPathRegexps {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Level returns the level a request is logged at from its status and latency.
+func (o *MiddlewareOptions) Level(status int, latency time.Duration) slog.Level {
+	if o == nil {
+		return DefaultStatusLevel(status)
+	}
+
+	statusLevel := o.StatusLevel
+	if statusLevel == nil {
+		statusLevel = DefaultStatusLevel
+	}
+
+	level := statusLevel(status)
+	if o.LatencyError > 0 && latency > o.LatencyError {
+		level = max(level, slog.LevelError)
+	} else if o.LatencyWarn > 0 && latency > o.LatencyWarn {
+		level = max(level, slog.LevelWarn)
+	}
+	return level
+}
+
+// Log logs info as an http_request record with the fields enabled in o.
+func (o *MiddlewareOptions) Log(ctx context.Context, logger *slog.Logger, info RequestInfo) {
+	var opts MiddlewareOptions
+	if o != nil {
+		opts = *o
+	}
+
+	attrs := []slog.Attr{
+		slog.String("log_type", "http_request"),
+		slog.String("method", info.Method),
+		slog.String("path", info.Path),
+		slog.String("remote", info.Remote),
+		slog.Int("status", info.Status),
+		slog.Int64("bytes", info.Bytes),
+		slog.Duration("duration", info.Duration),
+		slog.String("request_id", info.RequestID),
+	}
+	if opts.Query && info.Query != "" {
+		attrs = append(attrs, slog.String("query", info.Query))
+	}
+	if opts.UserAgent && info.UserAgent != "" {
+		attrs = append(attrs, slog.String("user_agent", info.UserAgent))
+	}
+	if opts.Referer && info.Referer != "" {
+		attrs = append(attrs, slog.String("referer", info.Referer))
+	}
+	if opts.Host && info.Host != "" {
+		attrs = append(attrs, slog.String("host", info.Host))
+	}
+	if opts.Protocol && info.Protocol != "" {
+		attrs = append(attrs, slog.String("protocol", info.Protocol))
+	}
+	if opts.RoutePattern && info.Pattern != "" {
+		attrs = append(attrs, slog.String("pattern", info.Pattern))
+	}
+	if opts.ContentLength && info.ContentLength >= 0 {
+		attrs = append(attrs, slog.Int64("content_length", info.ContentLength))
+	}
+
+	attrs = append(attrs, o.captureAttrs(info)...)
+
+	level := o.Level(info.Status, info.Duration)
+	if info.Panic != nil {
+		level = slog.LevelError
+		attrs = append(attrs,
+			slog.String("panic", fmt.Sprint(info.Panic)),
+			slog.String("stack", cleanStack(info.Stack)),
+		)
+	}
+
+	logger.LogAttrs(ctx, level, "", attrs...)
+}
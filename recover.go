@@ -0,0 +1,126 @@
+package sloghuman
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+type (
+	// RequestPanic holds a panic recovered by a recoverer middleware so the
+	// logger middleware of the same request can include it in its record.
+	RequestPanic struct {
+		Value any
+		Stack []byte
+	}
+
+	// requestPanicKey is the context key of the requests RequestPanic.
+	requestPanicKey struct{}
+)
+
+// ContextWithPanic returns a copy of ctx holding a RequestPanic. The logger
+// middlewares use it so panics recovered further down the chain are logged in
+// their record instead of a separate one.
+func ContextWithPanic(ctx context.Context) (context.Context, *RequestPanic) {
+	p := &RequestPanic{}
+	return context.WithValue(ctx, requestPanicKey{}, p), p
+}
+
+// LogPanic records a panic recovered from the request described by info. When
+// a logger middleware created a RequestPanic with ContextWithPanic the panic is
+// added to its record, otherwise info is logged as an ERROR http_request record.
+func LogPanic(ctx context.Context, logger *slog.Logger, info RequestInfo, value any, stack []byte) {
+	if p, ok := ctx.Value(requestPanicKey{}).(*RequestPanic); ok {
+		p.Value = value
+		p.Stack = stack
+		return
+	}
+
+	info.Panic = value
+	info.Stack = stack
+	(*MiddlewareOptions)(nil).Log(ctx, logger, info)
+}
+
+// HTTPRecoverer is net/http middleware that recovers panics in the handler,
+// logs them with a cleaned up stack and responds with 500. It should be used
+// after HTTPLogger so the panic is logged in its record.
+func HTTPRecoverer(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t1 := time.Now()
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// ErrAbortHandler is used to abort a response and is handled by the server
+				if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(p)
+				}
+				stack := debug.Stack()
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+
+				info := NewRequestInfo(r)
+				info.Status = http.StatusInternalServerError
+				info.Duration = time.Since(t1)
+				info.RequestID = r.Header.Get(RequestIDHeader)
+				LogPanic(r.Context(), logger, info, p, stack)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// cleanStack turns a stack from debug.Stack into one frame per line, written as
+// function (file:line). The goroutine header, argument values, program counter
+// offsets and the frames of the recovery itself are removed.
+func cleanStack(stack []byte) string {
+	var (
+		frames   []string
+		function string
+		scanner  = bufio.NewScanner(bytes.NewReader(stack))
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "goroutine "), line == "":
+			continue
+		case strings.HasPrefix(line, "created by "):
+			function, _, _ = strings.Cut(line, " in goroutine ")
+		case strings.HasPrefix(line, "\t"):
+			if function == "" {
+				continue
+			}
+			location := strings.TrimSpace(line)
+			if i := strings.LastIndex(location, " +0x"); i >= 0 {
+				location = location[:i]
+			}
+			frames = append(frames, function+" ("+location+")")
+			function = ""
+		default:
+			function = line
+			if i := strings.LastIndexByte(function, '('); i > 0 {
+				function = function[:i]
+			}
+			// Everything before the panic is the recovery collecting the stack
+			if function == "panic" {
+				frames = frames[:0]
+				function = ""
+			}
+		}
+	}
+
+	return strings.Join(frames, "\n")
+}
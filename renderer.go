@@ -0,0 +1,206 @@
+package sloghuman
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+type (
+	// LogTypeRenderer lays out the log line for records with a matching log_type attr.
+	// Register one with RegisterLogType.
+	LogTypeRenderer struct {
+		// Keys are the top level attr keys used by the renderer. Their values are
+		// passed to Render in the LogEntry and are not printed with the remaining
+		// key=value attrs.
+		Keys []string
+
+		// Render returns the body of the line. It is printed after the level,
+		// request id, time and source, any remaining attrs are appended after it.
+		Render func(e LogEntry) string
+
+		// Block optionally returns lines printed beneath the line, each ending
+		// in a newline.
+		Block func(e LogEntry) string
+	}
+
+	// LogEntry is passed to a LogTypeRenderer when rendering a line.
+	LogEntry struct {
+		// LogType is the value of the log_type attr.
+		LogType string
+
+		// Message is the record message.
+		Message string
+
+		// Attrs holds the values of the renderers Keys found on the record or handler.
+		Attrs map[string]slog.Value
+
+		h *TextHandler
+	}
+)
+
+// logTypes is the registry of log type renderers. The TextHandler falls back to
+// defaultRenderer for log types that are not registered.
+var logTypes = struct {
+	mx        sync.RWMutex
+	renderers map[string]LogTypeRenderer
+}{
+	renderers: map[string]LogTypeRenderer{
+		"http_request": httpRequestRenderer,
+		"sql_query":    sqlQueryRenderer,
+		"grpc_call":    grpcCallRenderer,
+	},
+}
+
+// RegisterLogType registers the renderer used by text handlers for records with
+// a log_type attr matching logType. Registering an existing log type replaces it,
+// including the built-in renderers.
+//
+//	logger.RegisterLogType("job_run", logger.LogTypeRenderer{
+//		Keys: []string{"job", "status"},
+//		Render: func(e logger.LogEntry) string {
+//			return e.Colorize("Job", logger.ColorLogType) + " | " + e.Attr("job") + " " +
+//				e.Colorize(e.Attr("status"), logger.ColorStatus) + " " + e.Colorize(e.Message, logger.ColorMessage)
+//		},
+//	})
+func RegisterLogType(logType string, r LogTypeRenderer) {
+	logTypes.mx.Lock()
+	defer logTypes.mx.Unlock()
+	logTypes.renderers[logType] = r
+}
+
+// UnregisterLogType removes the renderer registered for logType, records with it
+// use the default layout again.
+func UnregisterLogType(logType string) {
+	logTypes.mx.Lock()
+	defer logTypes.mx.Unlock()
+	delete(logTypes.renderers, logType)
+}
+
+// lookupLogType returns the renderer for logType or the default renderer.
+func lookupLogType(logType string) LogTypeRenderer {
+	logTypes.mx.RLock()
+	defer logTypes.mx.RUnlock()
+	if r, ok := logTypes.renderers[logType]; ok && r.Render != nil {
+		return r
+	}
+	return defaultRenderer
+}
+
+// Attr returns the string value of key, or an empty string if it was not set.
+func (e LogEntry) Attr(key string) string {
+	v, ok := e.Attrs[key]
+	if !ok {
+		return ""
+	}
+	return v.String()
+}
+
+// Colorize colors value using the handlers palette for the given ColorType.
+// Nothing is colored when the handler has color disabled.
+func (e LogEntry) Colorize(value string, t ColorType) string {
+	return e.h.colorize(value, t)
+}
+
+// Paint wraps value in the provided escape code, e.g. a field of Palette.
+// Nothing is colored when the handler has color disabled.
+func (e LogEntry) Paint(code, value string) string {
+	if e.h.noColor || value == "" {
+		return value
+	}
+	return e.h.paint(code, value)
+}
+
+// Palette returns the color palette of the handler.
+func (e LogEntry) Palette() ColorPalette {
+	return e.h.palette
+}
+
+// defaultRenderer is used for records without a log_type or with an unregistered one.
+var defaultRenderer = LogTypeRenderer{
+	Render: func(e LogEntry) string {
+		if e.LogType == "" {
+			return e.Colorize(e.Message, ColorMessage)
+		}
+		return e.Colorize(e.LogType, ColorLogType) + " | " + e.Colorize(e.Message, ColorMessage)
+	},
+}
+
+// httpRequestRenderer is the built-in renderer for the http_request log type.
+var httpRequestRenderer = LogTypeRenderer{
+	Keys: []string{"method", "path", "status", "remote", "duration", "bytes", "request_body", "response_body", "panic", "stack"},
+	Render: func(e LogEntry) string {
+		// Pad method to keep lines pretty
+		method := e.Colorize(fmt.Sprintf("%-7s", e.Attr("method")), ColorMethod)
+
+		// Bytes suffix
+		bytes := e.Attr("bytes")
+		if bytes != "" {
+			bytes += "B"
+		}
+
+		// Bytes and duration section
+		bytesTime := ""
+		if duration := e.Attr("duration"); bytes != "" || duration != "" {
+			bytesTime = fmt.Sprintf(" [%5s %10s] ", bytes, duration)
+		}
+
+		return fmt.Sprintf("%s | %s %s %s %s%s %s",
+			e.Colorize("HTTP Request", ColorLogType),
+			e.Colorize(e.Attr("status"), ColorStatus), method,
+			e.Colorize(e.Attr("path"), ColorPath), e.Attr("remote"),
+			bytesTime, e.Colorize(e.Message, ColorMessage),
+		)
+	},
+	Block: func(e LogEntry) string {
+		return e.panicBlock() + e.bodyBlock("request_body") + e.bodyBlock("response_body")
+	},
+}
+
+// panicBlock returns the recovered panic and its stack as an indented block.
+// Each frame is written as function (file:line).
+func (e LogEntry) panicBlock() string {
+	value := e.Attr("panic")
+	if value == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(groupIndent + e.Colorize("panic", ColorLogType) + ": " + e.Paint(e.Palette().LevelERROR, value) + "\n")
+	if stack := e.Attr("stack"); stack != "" {
+		b.WriteString(groupIndent + e.Colorize("stack", ColorLogType) + ":\n")
+		for _, frame := range strings.Split(stack, "\n") {
+			if function, location, ok := strings.Cut(frame, " ("); ok {
+				frame = e.Colorize(function, ColorPath) + " (" + e.Colorize(strings.TrimSuffix(location, ")"), ColorLine) + ")"
+			}
+			b.WriteString(groupIndent + groupIndent + frame + "\n")
+		}
+	}
+	return b.String()
+}
+
+// bodyBlock returns the body stored in key as an indented block, pretty printing
+// JSON when the handler has PrettyBodies set.
+func (e LogEntry) bodyBlock(key string) string {
+	body := e.Attr(key)
+	if body == "" {
+		return ""
+	}
+
+	if e.h.prettyBodies {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(body), "", groupIndent); err == nil {
+			body = buf.String()
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(groupIndent + e.Colorize(key, ColorLogType) + ":\n")
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		b.WriteString(groupIndent + groupIndent + line + "\n")
+	}
+	return b.String()
+}
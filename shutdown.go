@@ -0,0 +1,99 @@
+package sloghuman
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+type (
+	// flusher is implemented by writers that hold lines until they are sent, such as HTTPWriter.
+	flusher interface {
+		Flush(ctx context.Context) error
+	}
+
+	// contextCloser is implemented by writers that flush and close within the
+	// deadline of ctx instead of their own, such as HTTPWriter.
+	contextCloser interface {
+		closeContext(ctx context.Context) error
+	}
+)
+
+// flushers holds the writers of the loggers created by NewLoggerMultiHandler
+// that Shutdown flushes.
+var flushers = struct {
+	mx      sync.Mutex
+	writers map[flusher]struct{}
+}{writers: make(map[flusher]struct{})}
+
+// Shutdown flushes every writer with a Flush(context.Context) error method,
+// such as HTTPWriter, used by a logger created with NewLoggerMultiHandler, then
+// closes the ones that are an io.Closer, even when the flush failed. It waits
+// until the writers have sent their lines or ctx is done and returns the errors
+// of every writer joined. Call it before main returns so no logs are lost.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := logger.Shutdown(ctx); err != nil {
+//		fmt.Fprintln(os.Stderr, err)
+//	}
+func Shutdown(ctx context.Context) error {
+	flushers.mx.Lock()
+	writers := make([]flusher, 0, len(flushers.writers))
+	for w := range flushers.writers {
+		writers = append(writers, w)
+	}
+	clear(flushers.writers)
+	flushers.mx.Unlock()
+
+	// Writers are flushed at the same time so they share the deadline
+	var (
+		wg   sync.WaitGroup
+		mx   sync.Mutex
+		errs []error
+	)
+	for _, w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if c, ok := w.(contextCloser); ok {
+				err = c.closeContext(ctx)
+			} else {
+				err = w.Flush(ctx)
+				if c, ok := w.(io.Closer); ok {
+					err = errors.Join(err, c.Close())
+				}
+			}
+			if err != nil {
+				mx.Lock()
+				errs = append(errs, err)
+				mx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// registerFlusher adds w to the writers flushed by Shutdown if it is a flusher.
+func registerFlusher(w io.Writer) {
+	f, ok := w.(flusher)
+	// Writers are kept in a map, which panics on values that are not comparable
+	if !ok || !reflect.TypeOf(f).Comparable() {
+		return
+	}
+	flushers.mx.Lock()
+	flushers.writers[f] = struct{}{}
+	flushers.mx.Unlock()
+}
+
+// unregisterFlusher removes a closed writer from the writers flushed by Shutdown.
+func unregisterFlusher(w flusher) {
+	flushers.mx.Lock()
+	delete(flushers.writers, w)
+	flushers.mx.Unlock()
+}
@@ -0,0 +1,146 @@
+package sloghuman
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultSlowQuery is the default duration above which sql_query entries are
+// flagged as slow, see TextOptions.SlowQuery.
+const DefaultSlowQuery = 500 * time.Millisecond
+
+// sqlKeywords are the words highlighted in sql_query entries.
+var sqlKeywords = wordSet(`
+	SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE
+	JOIN LEFT RIGHT INNER OUTER FULL CROSS ON USING AS GROUP BY ORDER HAVING
+	LIMIT OFFSET RETURNING WITH RECURSIVE UNION ALL DISTINCT CREATE TABLE DROP
+	ALTER ADD INDEX PRIMARY KEY FOREIGN REFERENCES NULL IS IN LIKE ILIKE
+	BETWEEN EXISTS CASE WHEN THEN ELSE END ASC DESC BEGIN COMMIT ROLLBACK
+	CONFLICT DO NOTHING DEFAULT IF TRUNCATE FOR SHARE`)
+
+// sqlQueryRenderer is the built-in renderer for the sql_query log type.
+var sqlQueryRenderer = LogTypeRenderer{
+	Keys: []string{"query", "args", "rows", "duration", "db"},
+	Render: func(e LogEntry) string {
+		var b strings.Builder
+		b.WriteString(e.Colorize("SQL Query", ColorLogType))
+		b.WriteString(" |")
+
+		if db := e.Attr("db"); db != "" {
+			b.WriteString(" " + e.Colorize(db, ColorPath))
+		}
+
+		// Rows and duration section
+		rows := e.Attr("rows")
+		if rows != "" {
+			rows += " rows"
+		}
+		if duration := e.Attr("duration"); rows != "" || duration != "" {
+			fmt.Fprintf(&b, " [%9s %10s]", rows, duration)
+		}
+		if isSlowQuery(e.Attrs["duration"], e.h.slowQuery) {
+			b.WriteString(" " + e.Paint(e.Palette().LevelWARN, "SLOW"))
+		}
+
+		if query := e.Attr("query"); query != "" {
+			b.WriteString(" " + highlightSQL(e, query))
+		}
+		if args, ok := e.Attrs["args"]; ok {
+			fmt.Fprintf(&b, " args=%s", args)
+		}
+		if e.Message != "" {
+			b.WriteString(" " + e.Colorize(e.Message, ColorMessage))
+		}
+		return b.String()
+	},
+}
+
+// isSlowQuery reports whether the duration is above threshold. Durations
+// logged as strings are parsed with time.ParseDuration.
+func isSlowQuery(v slog.Value, threshold time.Duration) bool {
+	if threshold <= 0 {
+		return false
+	}
+
+	var d time.Duration
+	switch v.Kind() {
+	case slog.KindDuration:
+		d = v.Duration()
+	case slog.KindString:
+		var err error
+		if d, err = time.ParseDuration(v.String()); err != nil {
+			return false
+		}
+	default:
+		return false
+	}
+	return d > threshold
+}
+
+// highlightSQL collapses the whitespace in query and colors its keywords.
+// Quoted strings and identifiers are left untouched.
+func highlightSQL(e LogEntry, query string) string {
+	var (
+		b     strings.Builder
+		runes = []rune(strings.TrimSpace(query))
+		space bool
+	)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			i++
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end < len(runes) {
+				end++
+			}
+			writeSQLSpace(&b, &space)
+			b.WriteString(string(runes[i:end]))
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			end := i + 1
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			word := string(runes[i:end])
+			writeSQLSpace(&b, &space)
+			if _, ok := sqlKeywords[strings.ToUpper(word)]; ok {
+				word = e.Colorize(word, ColorLogType)
+			}
+			b.WriteString(word)
+			i = end
+		default:
+			writeSQLSpace(&b, &space)
+			b.WriteRune(r)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// writeSQLSpace writes a single space for a run of collapsed whitespace.
+func writeSQLSpace(b *strings.Builder, space *bool) {
+	if *space {
+		b.WriteByte(' ')
+		*space = false
+	}
+}
+
+// wordSet returns the set of space separated words in s.
+func wordSet(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, w := range strings.Fields(s) {
+		set[w] = struct{}{}
+	}
+	return set
+}
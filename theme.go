@@ -0,0 +1,595 @@
+package sloghuman
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Color is an abstract 24-bit RGB color. The zero Color means no color is set
+	// and the terminals default foreground is used. Use RGB or Hex to create one.
+	Color uint32
+
+	// Style is an abstract terminal style made from a foreground Color and text attributes.
+	// It is rendered to an ANSI escape sequence for a given ColorDepth.
+	Style struct {
+		Fg        Color
+		Bold      bool
+		Dim       bool
+		Italic    bool
+		Underline bool
+		Reverse   bool
+	}
+
+	// Theme is a palette defined using abstract styles instead of escape sequences.
+	// Use Palette to render it to a ColorPalette for a specific ColorDepth.
+	Theme struct {
+		MethodGET     Style
+		MethodPOST    Style
+		MethodPUT     Style
+		MethodDELETE  Style
+		MethodPATCH   Style
+		MethodOPTIONS Style
+		MethodHEAD    Style
+		MethodTRACE   Style
+		MethodCONNECT Style
+
+		Status2xx Style
+		Status3xx Style
+		Status4xx Style
+		Status5xx Style
+
+		LevelDEBUG Style
+		LevelINFO  Style
+		LevelWARN  Style
+		LevelERROR Style
+
+		RequestID Style
+		TraceID   Style
+		Path      Style
+		Line      Style
+		LogType   Style
+		Message   Style
+	}
+
+	// ColorDepth is the number of colors a terminal supports
+	ColorDepth int
+)
+
+// Enums used to set the color depth a Theme is rendered at
+const (
+	// ColorDepthAuto detects the color depth from the COLORTERM and TERM environment variables.
+	ColorDepthAuto ColorDepth = iota
+	ColorDepth16
+	ColorDepth256
+	ColorDepthTrueColor
+)
+
+func (d ColorDepth) String() string {
+	names := [...]string{"Auto", "16", "256", "TrueColor"}
+	if d < 0 || int(d) >= len(names) {
+		return fmt.Sprintf("ColorDepth(%d)", int(d))
+	}
+	return names[d]
+}
+
+// colorSet marks a Color as set so black can be told apart from no color.
+const colorSet = 1 << 24
+
+// RGB returns the Color for the provided red, green and blue values.
+func RGB(r, g, b uint8) Color {
+	return Color(colorSet | uint32(r)<<16 | uint32(g)<<8 | uint32(b))
+}
+
+// Hex returns the Color for a hex value such as 0x50fa7b.
+func Hex(hex uint32) Color {
+	return Color(colorSet | hex&0xffffff)
+}
+
+// IsSet reports whether c is a color rather than the zero Color.
+func (c Color) IsSet() bool {
+	return c&colorSet != 0
+}
+
+// RGB returns the red, green and blue values of c.
+func (c Color) RGB() (r, g, b uint8) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c)
+}
+
+// Escape renders the style to an ANSI escape sequence at the provided depth.
+// An empty style renders an empty string.
+func (s Style) Escape(depth ColorDepth) string {
+	if depth == ColorDepthAuto {
+		depth = detectColorDepth()
+	}
+
+	var codes []string
+	if s.Bold {
+		codes = append(codes, "1")
+	}
+	if s.Dim {
+		codes = append(codes, "2")
+	}
+	if s.Italic {
+		codes = append(codes, "3")
+	}
+	if s.Underline {
+		codes = append(codes, "4")
+	}
+	if s.Reverse {
+		codes = append(codes, "7")
+	}
+
+	if s.Fg.IsSet() {
+		r, g, b := s.Fg.RGB()
+		switch depth {
+		case ColorDepthTrueColor:
+			codes = append(codes, "38;2;"+strconv.Itoa(int(r))+";"+strconv.Itoa(int(g))+";"+strconv.Itoa(int(b)))
+		case ColorDepth256:
+			codes = append(codes, "38;5;"+strconv.Itoa(to256(r, g, b)))
+		default:
+			codes = append(codes, strconv.Itoa(to16(r, g, b)))
+		}
+	}
+
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\033[" + strings.Join(codes, ";") + "m"
+}
+
+// Palette renders the theme to a ColorPalette at the provided depth.
+// ColorDepthAuto detects the depth from the environment.
+func (t Theme) Palette(depth ColorDepth) ColorPalette {
+	if depth == ColorDepthAuto {
+		depth = detectColorDepth()
+	}
+
+	return ColorPalette{
+		MethodGET:     t.MethodGET.Escape(depth),
+		MethodPOST:    t.MethodPOST.Escape(depth),
+		MethodPUT:     t.MethodPUT.Escape(depth),
+		MethodDELETE:  t.MethodDELETE.Escape(depth),
+		MethodPATCH:   t.MethodPATCH.Escape(depth),
+		MethodOPTIONS: t.MethodOPTIONS.Escape(depth),
+		MethodHEAD:    t.MethodHEAD.Escape(depth),
+		MethodTRACE:   t.MethodTRACE.Escape(depth),
+		MethodCONNECT: t.MethodCONNECT.Escape(depth),
+
+		Status2xx: t.Status2xx.Escape(depth),
+		Status3xx: t.Status3xx.Escape(depth),
+		Status4xx: t.Status4xx.Escape(depth),
+		Status5xx: t.Status5xx.Escape(depth),
+
+		LevelDEBUG: t.LevelDEBUG.Escape(depth),
+		LevelINFO:  t.LevelINFO.Escape(depth),
+		LevelWARN:  t.LevelWARN.Escape(depth),
+		LevelERROR: t.LevelERROR.Escape(depth),
+
+		RequestID: t.RequestID.Escape(depth),
+		TraceID:   t.TraceID.Escape(depth),
+		Path:      t.Path.Escape(depth),
+		Line:      t.Line.Escape(depth),
+		LogType:   t.LogType.Escape(depth),
+		Message:   t.Message.Escape(depth),
+		Reset:     "\033[0m",
+	}
+}
+
+// detectColorDepth returns the color depth of the terminal using the COLORTERM
+// and TERM environment variables. When TERM is not set 256 colors are assumed.
+func detectColorDepth() ColorDepth {
+	if envIs(os.Getenv("COLORTERM"), "truecolor", "24bit") || os.Getenv("WT_SESSION") != "" {
+		return ColorDepthTrueColor
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case strings.Contains(term, "truecolor"), strings.Contains(term, "24bit"), strings.Contains(term, "direct"):
+		return ColorDepthTrueColor
+	case strings.Contains(term, "256color"), term == "":
+		return ColorDepth256
+	default:
+		return ColorDepth16
+	}
+}
+
+// cubeLevels are the channel values used by the 6x6x6 cube of the 256 color palette.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// to256 returns the closest color in the 256 color palette using either the
+// 6x6x6 color cube or the grayscale ramp.
+func to256(r, g, b uint8) int {
+	cube := func(v uint8) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		default:
+			return (int(v) - 35) / 40
+		}
+	}
+	ri, gi, bi := cube(r), cube(g), cube(b)
+	cubeIndex := 16 + 36*ri + 6*gi + bi
+	cubeDist := distance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIndex := min(23, max(0, (avg-3)/10))
+	grayLevel := 8 + 10*grayIndex
+	grayDist := distance(r, g, b, grayLevel, grayLevel, grayLevel)
+
+	if grayDist < cubeDist {
+		return 232 + grayIndex
+	}
+	return cubeIndex
+}
+
+// to16 returns the SGR foreground code of the closest of the 16 basic colors.
+// Colors are matched by hue rather than distance so pastel theme colors keep
+// their hue instead of collapsing to gray.
+func to16(r, g, b uint8) int {
+	hi := max(int(r), int(g), int(b))
+	lo := min(int(r), int(g), int(b))
+	chroma := hi - lo
+
+	// grays
+	if chroma < 40 {
+		switch {
+		case hi < 64:
+			return 30 // black
+		case hi < 160:
+			return 90 // bright black
+		case hi < 224:
+			return 37 // white
+		default:
+			return 97 // bright white
+		}
+	}
+
+	var hue int
+	switch hi {
+	case int(r):
+		hue = (60*(int(g)-int(b))/chroma + 360) % 360
+	case int(g):
+		hue = 60*(int(b)-int(r))/chroma + 120
+	default:
+		hue = 60*(int(r)-int(g))/chroma + 240
+	}
+
+	var base int
+	switch {
+	case hue < 30 || hue >= 330:
+		base = 1 // red
+	case hue < 90:
+		base = 3 // yellow
+	case hue < 150:
+		base = 2 // green
+	case hue < 210:
+		base = 6 // cyan
+	case hue < 270:
+		base = 4 // blue
+	default:
+		base = 5 // magenta
+	}
+
+	if hi > 200 {
+		return 90 + base
+	}
+	return 30 + base
+}
+
+// distance returns the squared distance between two colors.
+func distance(r, g, b uint8, r2, g2, b2 int) int {
+	dr, dg, db := int(r)-r2, int(g)-g2, int(b)-b2
+	return dr*dr + dg*dg + db*db
+}
+
+// Below are the predefined themes. They are rendered to a ColorPalette at the
+// color depth of the terminal, use TextOptions.Theme to set one.
+var (
+	// Dracula
+	DraculaTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x50fa7b)},
+		MethodPOST:    Style{Fg: Hex(0x8be9fd)},
+		MethodPUT:     Style{Fg: Hex(0xf1fa8c)},
+		MethodDELETE:  Style{Fg: Hex(0xff5555)},
+		MethodPATCH:   Style{Fg: Hex(0xf1fa8c)},
+		MethodHEAD:    Style{Fg: Hex(0x50fa7b)},
+		MethodOPTIONS: Style{Fg: Hex(0xbd93f9)},
+		MethodTRACE:   Style{Fg: Hex(0x6272a4)},
+		MethodCONNECT: Style{Fg: Hex(0x6272a4)},
+
+		Status2xx: Style{Fg: Hex(0x50fa7b)},
+		Status3xx: Style{Fg: Hex(0x8be9fd)},
+		Status4xx: Style{Fg: Hex(0xf1fa8c)},
+		Status5xx: Style{Fg: Hex(0xff5555)},
+
+		LevelDEBUG: Style{Fg: Hex(0x8be9fd)},
+		LevelINFO:  Style{Fg: Hex(0x50fa7b)},
+		LevelWARN:  Style{Fg: Hex(0xf1fa8c)},
+		LevelERROR: Style{Fg: Hex(0xff5555)},
+
+		RequestID: Style{Fg: Hex(0xff79c6)},
+		TraceID:   Style{Fg: Hex(0xbd93f9)},
+		Path:      Style{Fg: Hex(0x8be9fd)},
+		Line:      Style{Fg: Hex(0x8be9fd)},
+		LogType:   Style{Fg: Hex(0xbd93f9)},
+		Message:   Style{Fg: Hex(0xbd93f9)},
+	}
+
+	// Nord
+	NordTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x88c0d0)},
+		MethodPOST:    Style{Fg: Hex(0x81a1c1)},
+		MethodPUT:     Style{Fg: Hex(0xebcb8b)},
+		MethodDELETE:  Style{Fg: Hex(0xbf616a)},
+		MethodPATCH:   Style{Fg: Hex(0xebcb8b)},
+		MethodHEAD:    Style{Fg: Hex(0x88c0d0)},
+		MethodOPTIONS: Style{Fg: Hex(0xb48ead)},
+		MethodTRACE:   Style{Fg: Hex(0x616e88)},
+		MethodCONNECT: Style{Fg: Hex(0x616e88)},
+
+		Status2xx: Style{Fg: Hex(0xa3be8c)},
+		Status3xx: Style{Fg: Hex(0x88c0d0)},
+		Status4xx: Style{Fg: Hex(0xebcb8b)},
+		Status5xx: Style{Fg: Hex(0xbf616a)},
+
+		LevelDEBUG: Style{Fg: Hex(0x88c0d0)},
+		LevelINFO:  Style{Fg: Hex(0xa3be8c)},
+		LevelWARN:  Style{Fg: Hex(0xebcb8b)},
+		LevelERROR: Style{Fg: Hex(0xbf616a)},
+
+		RequestID: Style{Fg: Hex(0xb48ead)},
+		TraceID:   Style{Fg: Hex(0x88c0d0)},
+		Path:      Style{Fg: Hex(0x81a1c1)},
+		Line:      Style{Fg: Hex(0x616e88)},
+		LogType:   Style{Fg: Hex(0x8fbcbb)},
+		Message:   Style{Fg: Hex(0x8fbcbb)},
+	}
+
+	// Gruvbox Dark
+	GruvboxDarkTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0xb8bb26)},
+		MethodPOST:    Style{Fg: Hex(0x8ec07c)},
+		MethodPUT:     Style{Fg: Hex(0xfe8019)},
+		MethodDELETE:  Style{Fg: Hex(0xfb4934)},
+		MethodPATCH:   Style{Fg: Hex(0xfe8019)},
+		MethodHEAD:    Style{Fg: Hex(0xb8bb26)},
+		MethodOPTIONS: Style{Fg: Hex(0xd3869b)},
+		MethodTRACE:   Style{Fg: Hex(0xa89984)},
+		MethodCONNECT: Style{Fg: Hex(0xa89984)},
+
+		Status2xx: Style{Fg: Hex(0xb8bb26)},
+		Status3xx: Style{Fg: Hex(0x8ec07c)},
+		Status4xx: Style{Fg: Hex(0xfe8019)},
+		Status5xx: Style{Fg: Hex(0xfb4934)},
+
+		LevelDEBUG: Style{Fg: Hex(0x8ec07c)},
+		LevelINFO:  Style{Fg: Hex(0xb8bb26)},
+		LevelWARN:  Style{Fg: Hex(0xfe8019)},
+		LevelERROR: Style{Fg: Hex(0xfb4934)},
+
+		RequestID: Style{Fg: Hex(0xd3869b)},
+		TraceID:   Style{Fg: Hex(0x83a598)},
+		Path:      Style{Fg: Hex(0x83a598)},
+		Line:      Style{Fg: Hex(0xa89984)},
+		LogType:   Style{Fg: Hex(0xfabd2f)},
+		Message:   Style{Fg: Hex(0xfabd2f)},
+	}
+
+	// Solarized Dark
+	SolarizedDarkTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x859900)},
+		MethodPOST:    Style{Fg: Hex(0x2aa198)},
+		MethodPUT:     Style{Fg: Hex(0xb58900)},
+		MethodDELETE:  Style{Fg: Hex(0xdc322f)},
+		MethodPATCH:   Style{Fg: Hex(0xb58900)},
+		MethodHEAD:    Style{Fg: Hex(0x859900)},
+		MethodOPTIONS: Style{Fg: Hex(0xb58900)},
+		MethodTRACE:   Style{Fg: Hex(0x839496)},
+		MethodCONNECT: Style{Fg: Hex(0x839496)},
+
+		Status2xx: Style{Fg: Hex(0x859900)},
+		Status3xx: Style{Fg: Hex(0x2aa198)},
+		Status4xx: Style{Fg: Hex(0xcb4b16)},
+		Status5xx: Style{Fg: Hex(0xdc322f)},
+
+		LevelDEBUG: Style{Fg: Hex(0x2aa198)},
+		LevelINFO:  Style{Fg: Hex(0x268bd2)},
+		LevelWARN:  Style{Fg: Hex(0xcb4b16)},
+		LevelERROR: Style{Fg: Hex(0xdc322f)},
+
+		RequestID: Style{Fg: Hex(0xb58900)},
+		TraceID:   Style{Fg: Hex(0x6c71c4)},
+		Path:      Style{Fg: Hex(0x268bd2)},
+		Line:      Style{Fg: Hex(0x839496)},
+		LogType:   Style{Fg: Hex(0xd33682)},
+		Message:   Style{Fg: Hex(0xd33682)},
+	}
+
+	// One Dark
+	OneDarkTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x98c379)},
+		MethodPOST:    Style{Fg: Hex(0x61afef)},
+		MethodPUT:     Style{Fg: Hex(0xe5c07b)},
+		MethodDELETE:  Style{Fg: Hex(0xe06c75)},
+		MethodPATCH:   Style{Fg: Hex(0xe5c07b)},
+		MethodHEAD:    Style{Fg: Hex(0x98c379)},
+		MethodOPTIONS: Style{Fg: Hex(0xc678dd)},
+		MethodTRACE:   Style{Fg: Hex(0xabb2bf)},
+		MethodCONNECT: Style{Fg: Hex(0xabb2bf)},
+
+		Status2xx: Style{Fg: Hex(0x98c379)},
+		Status3xx: Style{Fg: Hex(0x61afef)},
+		Status4xx: Style{Fg: Hex(0xe5c07b)},
+		Status5xx: Style{Fg: Hex(0xe06c75)},
+
+		LevelDEBUG: Style{Fg: Hex(0x61afef)},
+		LevelINFO:  Style{Fg: Hex(0x98c379)},
+		LevelWARN:  Style{Fg: Hex(0xe5c07b)},
+		LevelERROR: Style{Fg: Hex(0xe06c75)},
+
+		RequestID: Style{Fg: Hex(0xc678dd)},
+		TraceID:   Style{Fg: Hex(0x56b6c2)},
+		Path:      Style{Fg: Hex(0x61afef)},
+		Line:      Style{Fg: Hex(0xabb2bf)},
+		LogType:   Style{Fg: Hex(0xc678dd)},
+		Message:   Style{Fg: Hex(0xc678dd)},
+	}
+
+	// Solarized Light
+	SolarizedLightTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x859900)},
+		MethodPOST:    Style{Fg: Hex(0x2aa198)},
+		MethodPUT:     Style{Fg: Hex(0xb58900)},
+		MethodDELETE:  Style{Fg: Hex(0xdc322f)},
+		MethodPATCH:   Style{Fg: Hex(0xb58900)},
+		MethodHEAD:    Style{Fg: Hex(0x859900)},
+		MethodOPTIONS: Style{Fg: Hex(0x6c71c4)},
+		MethodTRACE:   Style{Fg: Hex(0x657b83)},
+		MethodCONNECT: Style{Fg: Hex(0x657b83)},
+
+		Status2xx: Style{Fg: Hex(0x859900)},
+		Status3xx: Style{Fg: Hex(0x2aa198)},
+		Status4xx: Style{Fg: Hex(0xcb4b16)},
+		Status5xx: Style{Fg: Hex(0xdc322f)},
+
+		LevelDEBUG: Style{Fg: Hex(0x2aa198)},
+		LevelINFO:  Style{Fg: Hex(0x268bd2)},
+		LevelWARN:  Style{Fg: Hex(0xcb4b16)},
+		LevelERROR: Style{Fg: Hex(0xdc322f)},
+
+		RequestID: Style{Fg: Hex(0xd33682)},
+		TraceID:   Style{Fg: Hex(0x6c71c4)},
+		Path:      Style{Fg: Hex(0x268bd2)},
+		Line:      Style{Fg: Hex(0x657b83)},
+		LogType:   Style{Fg: Hex(0x6c71c4)},
+		Message:   Style{Fg: Hex(0x586e75)},
+	}
+
+	// Gruvbox Light
+	GruvboxLightTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x79740e)},
+		MethodPOST:    Style{Fg: Hex(0x427b58)},
+		MethodPUT:     Style{Fg: Hex(0xaf3a03)},
+		MethodDELETE:  Style{Fg: Hex(0x9d0006)},
+		MethodPATCH:   Style{Fg: Hex(0xaf3a03)},
+		MethodHEAD:    Style{Fg: Hex(0x79740e)},
+		MethodOPTIONS: Style{Fg: Hex(0x8f3f71)},
+		MethodTRACE:   Style{Fg: Hex(0x7c6f64)},
+		MethodCONNECT: Style{Fg: Hex(0x7c6f64)},
+
+		Status2xx: Style{Fg: Hex(0x79740e)},
+		Status3xx: Style{Fg: Hex(0x427b58)},
+		Status4xx: Style{Fg: Hex(0xaf3a03)},
+		Status5xx: Style{Fg: Hex(0x9d0006)},
+
+		LevelDEBUG: Style{Fg: Hex(0x427b58)},
+		LevelINFO:  Style{Fg: Hex(0x79740e)},
+		LevelWARN:  Style{Fg: Hex(0xaf3a03)},
+		LevelERROR: Style{Fg: Hex(0x9d0006)},
+
+		RequestID: Style{Fg: Hex(0x8f3f71)},
+		TraceID:   Style{Fg: Hex(0x076678)},
+		Path:      Style{Fg: Hex(0x076678)},
+		Line:      Style{Fg: Hex(0x7c6f64)},
+		LogType:   Style{Fg: Hex(0xb57614)},
+		Message:   Style{Fg: Hex(0x3c3836)},
+	}
+
+	// GitHub Light
+	GitHubLightTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x1a7f37)},
+		MethodPOST:    Style{Fg: Hex(0x0969da)},
+		MethodPUT:     Style{Fg: Hex(0xbc4c00)},
+		MethodDELETE:  Style{Fg: Hex(0xcf222e)},
+		MethodPATCH:   Style{Fg: Hex(0xbc4c00)},
+		MethodHEAD:    Style{Fg: Hex(0x1a7f37)},
+		MethodOPTIONS: Style{Fg: Hex(0x8250df)},
+		MethodTRACE:   Style{Fg: Hex(0x6e7781)},
+		MethodCONNECT: Style{Fg: Hex(0x6e7781)},
+
+		Status2xx: Style{Fg: Hex(0x1a7f37)},
+		Status3xx: Style{Fg: Hex(0x0969da)},
+		Status4xx: Style{Fg: Hex(0x9a6700)},
+		Status5xx: Style{Fg: Hex(0xcf222e)},
+
+		LevelDEBUG: Style{Fg: Hex(0x0969da)},
+		LevelINFO:  Style{Fg: Hex(0x1a7f37)},
+		LevelWARN:  Style{Fg: Hex(0x9a6700)},
+		LevelERROR: Style{Fg: Hex(0xcf222e)},
+
+		RequestID: Style{Fg: Hex(0xbf3989)},
+		TraceID:   Style{Fg: Hex(0x8250df)},
+		Path:      Style{Fg: Hex(0x0550ae)},
+		Line:      Style{Fg: Hex(0x6e7781)},
+		LogType:   Style{Fg: Hex(0x8250df)},
+		Message:   Style{Fg: Hex(0x24292f)},
+	}
+
+	// Colorblind uses the Okabe-Ito palette. Success and failure are told apart
+	// by blue and vermillion along with bold, never by red and green.
+	// The message uses the terminals default color so it suits light and dark backgrounds.
+	ColorblindTheme = Theme{
+		MethodGET:     Style{Fg: Hex(0x56b4e9)},
+		MethodPOST:    Style{Fg: Hex(0x0072b2)},
+		MethodPUT:     Style{Fg: Hex(0xe69f00)},
+		MethodDELETE:  Style{Fg: Hex(0xd55e00), Bold: true},
+		MethodPATCH:   Style{Fg: Hex(0xe69f00)},
+		MethodHEAD:    Style{Fg: Hex(0x56b4e9)},
+		MethodOPTIONS: Style{Fg: Hex(0xcc79a7)},
+		MethodTRACE:   Style{Fg: Hex(0x999999)},
+		MethodCONNECT: Style{Fg: Hex(0x999999)},
+
+		Status2xx: Style{Fg: Hex(0x56b4e9)},
+		Status3xx: Style{Fg: Hex(0xcc79a7)},
+		Status4xx: Style{Fg: Hex(0xe69f00), Underline: true},
+		Status5xx: Style{Fg: Hex(0xd55e00), Bold: true},
+
+		LevelDEBUG: Style{Fg: Hex(0x999999)},
+		LevelINFO:  Style{Fg: Hex(0x56b4e9)},
+		LevelWARN:  Style{Fg: Hex(0xe69f00), Underline: true},
+		LevelERROR: Style{Fg: Hex(0xd55e00), Bold: true},
+
+		RequestID: Style{Fg: Hex(0xcc79a7)},
+		TraceID:   Style{Fg: Hex(0x56b4e9)},
+		Path:      Style{Fg: Hex(0xf0e442)},
+		Line:      Style{Fg: Hex(0x999999)},
+		LogType:   Style{Fg: Hex(0x0072b2)},
+		Message:   Style{},
+	}
+
+	// Monochrome uses only bold, underline and reverse so it works on any
+	// background and with any color vision.
+	MonochromeTheme = Theme{
+		MethodGET:     Style{},
+		MethodPOST:    Style{Bold: true},
+		MethodPUT:     Style{Bold: true},
+		MethodDELETE:  Style{Bold: true, Underline: true},
+		MethodPATCH:   Style{Bold: true},
+		MethodHEAD:    Style{},
+		MethodOPTIONS: Style{Underline: true},
+		MethodTRACE:   Style{Underline: true},
+		MethodCONNECT: Style{Underline: true},
+
+		Status2xx: Style{},
+		Status3xx: Style{Underline: true},
+		Status4xx: Style{Bold: true},
+		Status5xx: Style{Bold: true, Reverse: true},
+
+		LevelDEBUG: Style{},
+		LevelINFO:  Style{Bold: true},
+		LevelWARN:  Style{Bold: true, Underline: true},
+		LevelERROR: Style{Bold: true, Reverse: true},
+
+		RequestID: Style{Underline: true},
+		TraceID:   Style{Italic: true},
+		Path:      Style{Underline: true},
+		Line:      Style{},
+		LogType:   Style{Bold: true},
+		Message:   Style{},
+	}
+)
@@ -0,0 +1,330 @@
+package sloghuman
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ThemeFormat is the file format of a theme passed to ParseTheme
+type ThemeFormat int
+
+// Enums used to set the format of a theme file
+const (
+	ThemeFormatJSON ThemeFormat = iota
+	ThemeFormatTOML
+	ThemeFormatYAML
+)
+
+func (f ThemeFormat) String() string {
+	names := [...]string{"JSON", "TOML", "YAML"}
+	if f < 0 || int(f) >= len(names) {
+		return fmt.Sprintf("ThemeFormat(%d)", int(f))
+	}
+	return names[f]
+}
+
+// ThemeEnv is the environment variable used to select the theme of text handlers
+// that do not set TextOptions.Palette or TextOptions.Theme. It can be the name of
+// a built-in theme or the path to a theme file.
+const ThemeEnv = "SLOG_HUMAN_THEME"
+
+// themeExtendsKey is the theme file key used to start from a built-in theme.
+const themeExtendsKey = "extends"
+
+// builtinThemes are the themes that can be selected by name. Names are normalized
+// with normalizeName before lookup.
+var builtinThemes = map[string]*Theme{
+	"dracula":       &DraculaTheme,
+	"nord":          &NordTheme,
+	"gruvboxdark":   &GruvboxDarkTheme,
+	"solarizeddark": &SolarizedDarkTheme,
+	"onedark":       &OneDarkTheme,
+
+	"solarizedlight": &SolarizedLightTheme,
+	"gruvboxlight":   &GruvboxLightTheme,
+	"githublight":    &GitHubLightTheme,
+	"colorblind":     &ColorblindTheme,
+	"monochrome":     &MonochromeTheme,
+}
+
+// namedColors are the colors that can be used by name in a theme file.
+// They match the standard 16 terminal colors.
+var namedColors = map[string]Color{
+	"black":         RGB(0, 0, 0),
+	"red":           RGB(170, 0, 0),
+	"green":         RGB(0, 170, 0),
+	"yellow":        RGB(170, 170, 0),
+	"blue":          RGB(0, 0, 170),
+	"magenta":       RGB(170, 0, 170),
+	"cyan":          RGB(0, 170, 170),
+	"white":         RGB(170, 170, 170),
+	"gray":          RGB(85, 85, 85),
+	"grey":          RGB(85, 85, 85),
+	"brightblack":   RGB(85, 85, 85),
+	"brightred":     RGB(255, 85, 85),
+	"brightgreen":   RGB(85, 255, 85),
+	"brightyellow":  RGB(255, 255, 85),
+	"brightblue":    RGB(85, 85, 255),
+	"brightmagenta": RGB(255, 85, 255),
+	"brightcyan":    RGB(85, 255, 255),
+	"brightwhite":   RGB(255, 255, 255),
+}
+
+// LookupTheme returns the built-in theme with the provided name.
+// Names are case insensitive and ignore '-', '_' and spaces, e.g. "gruvbox-dark".
+func LookupTheme(name string) (Theme, bool) {
+	t, ok := builtinThemes[normalizeName(name)]
+	if !ok {
+		return Theme{}, false
+	}
+	return *t, true
+}
+
+// LoadTheme reads a theme file. The format is chosen from the file extension,
+// .json, .toml, .yaml or .yml. See ParseTheme for the contents of the file.
+func LoadTheme(path string) (Theme, error) {
+	var format ThemeFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = ThemeFormatJSON
+	case ".toml":
+		format = ThemeFormatTOML
+	case ".yaml", ".yml":
+		format = ThemeFormatYAML
+	default:
+		return Theme{}, fmt.Errorf("theme %s: unknown file extension", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+
+	t, err := ParseTheme(data, format)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// ParseTheme parses a theme from data in the provided format.
+//
+// A theme is a flat set of keys matching the Theme fields, either as written
+// (LevelERROR) or in snake case (level_error). Each value is a space separated
+// list of a color and style words. Colors can be a name (red, bright-cyan, gray),
+// or hex RGB (#ff5555 or #f55). Style words are bold, dim, italic, underline and
+// reverse. The extends key starts the theme from a built-in theme.
+//
+//	extends = "dracula"
+//	level_error = "bold #ff5555"
+//	path = "underline cyan"
+//
+// TOML and YAML files are limited to this flat layout. A TOML file may put the
+// keys under a single [theme] table, any other table, nested mapping or list is
+// an error.
+func ParseTheme(data []byte, format ThemeFormat) (Theme, error) {
+	var (
+		values map[string]string
+		err    error
+	)
+	switch format {
+	case ThemeFormatJSON:
+		err = json.Unmarshal(data, &values)
+	case ThemeFormatTOML:
+		values, err = parseFlat(data, "=")
+	case ThemeFormatYAML:
+		values, err = parseFlat(data, ":")
+	default:
+		err = fmt.Errorf("unknown theme format %d", format)
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var t Theme
+	if name, ok := values[themeExtendsKey]; ok {
+		base, ok := LookupTheme(name)
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown theme %q", name)
+		}
+		t = base
+		delete(values, themeExtendsKey)
+	}
+
+	fields := reflect.ValueOf(&t).Elem()
+	for key, spec := range values {
+		field := themeField(fields, key)
+		if !field.IsValid() {
+			return Theme{}, fmt.Errorf("unknown theme key %q", key)
+		}
+		style, err := parseStyle(spec)
+		if err != nil {
+			return Theme{}, fmt.Errorf("%s: %w", key, err)
+		}
+		field.Set(reflect.ValueOf(style))
+	}
+
+	return t, nil
+}
+
+// themeFromEnv returns the theme selected by the ThemeEnv environment variable.
+// Values containing a path separator or a file extension are loaded as a file,
+// anything else is looked up as a built-in theme.
+func themeFromEnv() (*Theme, error) {
+	v := strings.TrimSpace(os.Getenv(ThemeEnv))
+	if v == "" {
+		return nil, nil
+	}
+
+	if strings.ContainsRune(v, os.PathSeparator) || strings.Contains(v, "/") || filepath.Ext(v) != "" {
+		t, err := LoadTheme(v)
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	t, ok := LookupTheme(v)
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q", v)
+	}
+	return &t, nil
+}
+
+// themeField returns the Theme field matching key, or an invalid reflect.Value.
+func themeField(fields reflect.Value, key string) reflect.Value {
+	key = normalizeName(key)
+	for i := 0; i < fields.NumField(); i++ {
+		if normalizeName(fields.Type().Field(i).Name) == key {
+			return fields.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// parseStyle parses a style from a space separated list of a color and style words.
+func parseStyle(spec string) (Style, error) {
+	var s Style
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		switch word {
+		case "bold":
+			s.Bold = true
+		case "dim":
+			s.Dim = true
+		case "italic":
+			s.Italic = true
+		case "underline":
+			s.Underline = true
+		case "reverse":
+			s.Reverse = true
+		case "none", "default":
+			s.Fg = 0
+		default:
+			c, err := parseColor(word)
+			if err != nil {
+				return Style{}, err
+			}
+			s.Fg = c
+		}
+	}
+	return s, nil
+}
+
+// parseColor parses a named color or a hex RGB color in the form #rrggbb or #rgb.
+func parseColor(word string) (Color, error) {
+	if c, ok := namedColors[normalizeName(word)]; ok {
+		return c, nil
+	}
+
+	hex, ok := strings.CutPrefix(word, "#")
+	if !ok {
+		return 0, fmt.Errorf("unknown color or style %q", word)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, fmt.Errorf("invalid hex color %q", word)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex color %q", word)
+	}
+	return Hex(uint32(v)), nil
+}
+
+// parseFlat parses the flat key/value layout used by TOML and YAML theme files.
+// Comments, blank lines, the TOML [theme] table header and YAML document markers
+// are skipped, other tables and nesting are rejected.
+func parseFlat(data []byte, sep string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line == "---" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			header, _, _ := strings.Cut(line, "#")
+			if sep != "=" || strings.TrimSpace(header) != "[theme]" {
+				return nil, fmt.Errorf("line %d: unsupported table %s, theme files are flat", n, line)
+			}
+			continue
+		}
+		// Indentation starts a nested mapping in YAML
+		if sep == ":" && (raw[0] == ' ' || raw[0] == '\t') {
+			return nil, fmt.Errorf("line %d: nested keys are not supported, theme files are flat", n)
+		}
+
+		key, value, ok := strings.Cut(line, sep)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key %s value", n, sep)
+		}
+		key = unquote(strings.TrimSpace(key))
+
+		value = strings.TrimSpace(value)
+		if value == "" || value[0] == '#' {
+			return nil, fmt.Errorf("line %d: %s has no value, theme files are flat", n, key)
+		}
+		if value[0] == '"' || value[0] == '\'' {
+			end := strings.IndexByte(value[1:], value[0])
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated string", n)
+			}
+			value = value[1 : end+1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// unquote removes matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// normalizeName lower cases name and removes '-', '_' and spaces so names can be
+// matched regardless of how they are written.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
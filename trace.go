@@ -0,0 +1,261 @@
+package sloghuman
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the trace id and
+// the id of the callers span.
+const TraceparentHeader = "traceparent"
+
+// TraceFlagsSampled is the trace flag set when the caller may have recorded the trace.
+const TraceFlagsSampled byte = 0x01
+
+type (
+	// TraceContext is the W3C trace context of a request. TraceID and SpanID
+	// are lowercase hex, 32 and 16 characters long.
+	TraceContext struct {
+		TraceID string
+		SpanID  string
+		Flags   byte
+	}
+
+	// traceKey is the context key of the TraceContext stored by ContextWithTrace.
+	traceKey struct{}
+
+	// traceHandler adds the trace of the context to the records of a handler
+	// that has no other way to read it, such as the JSON handler. The trace is
+	// kept at the top level, so the groups and attrs added after the first
+	// group are replayed on base for records with a trace.
+	traceHandler struct {
+		slog.Handler
+		base slog.Handler
+		ops  []traceOp
+
+		// traced is set when trace_id was added at the top level with WithAttrs.
+		traced bool
+	}
+
+	// traceOp is a group or attrs added to a traceHandler after the first group.
+	traceOp struct {
+		group string
+		attrs []slog.Attr
+	}
+)
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for malformed headers.
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses a traceparent header, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+// Headers of future versions are accepted as long as they start with the
+// fields of version 00.
+func ParseTraceparent(s string) (TraceContext, error) {
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+
+	version, traceID, spanID, flags := s[:2], s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(version) || version == "ff" || (version == "00" && len(s) != 55) ||
+		(len(s) > 55 && s[55] != '-') {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(traceID) || isZeroID(traceID) || !isLowerHex(spanID) || isZeroID(spanID) || !isLowerHex(flags) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+
+	f, _ := hex.DecodeString(flags)
+	return TraceContext{TraceID: traceID, SpanID: spanID, Flags: f[0]}, nil
+}
+
+// NewTraceContext returns a sampled TraceContext with a new random trace and span id.
+func NewTraceContext() TraceContext {
+	return TraceContext{
+		TraceID: randomID(16),
+		SpanID:  randomID(8),
+		Flags:   TraceFlagsSampled,
+	}
+}
+
+// NewSpan returns a child of t, the same trace with a new span id.
+func (t TraceContext) NewSpan() TraceContext {
+	t.SpanID = randomID(8)
+	return t
+}
+
+// IsValid reports whether t has a trace and span id.
+func (t TraceContext) IsValid() bool {
+	return t.TraceID != "" && t.SpanID != ""
+}
+
+// Sampled reports whether the sampled flag is set.
+func (t TraceContext) Sampled() bool {
+	return t.Flags&TraceFlagsSampled != 0
+}
+
+// String returns t as a version 00 traceparent header.
+func (t TraceContext) String() string {
+	return "00-" + t.TraceID + "-" + t.SpanID + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// ContextWithTrace returns a copy of ctx holding the trace context t.
+func ContextWithTrace(ctx context.Context, t TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// TraceFromContext returns the trace context stored by ContextWithTrace.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	t, ok := ctx.Value(traceKey{}).(TraceContext)
+	return t, ok && t.IsValid()
+}
+
+// SetTraceparent sets the traceparent header of an outgoing request to the
+// trace stored in ctx so the called service continues the same trace.
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	logger.SetTraceparent(ctx, req.Header)
+func SetTraceparent(ctx context.Context, header http.Header) {
+	if t, ok := TraceFromContext(ctx); ok {
+		header.Set(TraceparentHeader, t.String())
+	}
+}
+
+// HTTPTraceparent is net/http middleware that continues the trace of the
+// traceparent header, or starts a new one when it is missing or invalid, and
+// stores it in the request context with a new span id for the request. Text
+// handlers print the short trace id of records logged with the context and
+// JSON handlers add trace_id and span_id. It should be used before HTTPLogger.
+func HTTPTraceparent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, err := ParseTraceparent(r.Header.Get(TraceparentHeader))
+		if err != nil {
+			t = NewTraceContext()
+		} else {
+			t = t.NewSpan()
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithTrace(r.Context(), t)))
+	})
+}
+
+// TraceExtractor adds the trace stored by ContextWithTrace as trace_id and span_id.
+// The text handler already reads the trace id from the context, use it to print
+// the span id as well.
+var TraceExtractor ContextExtractor = func(ctx context.Context) []slog.Attr {
+	t, ok := TraceFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []slog.Attr{slog.String("trace_id", t.TraceID), slog.String("span_id", t.SpanID)}
+}
+
+// newTraceHandler returns h wrapped in a traceHandler.
+func newTraceHandler(h slog.Handler) *traceHandler {
+	return &traceHandler{Handler: h, base: h}
+}
+
+// Handle adds trace_id and span_id to r when ctx holds a trace and neither r
+// nor the handler have them.
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil || h.traced {
+		return h.Handler.Handle(ctx, r)
+	}
+	t, ok := TraceFromContext(ctx)
+	if !ok {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = a.Key == "trace_id"
+		return !found
+	})
+	if found {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	trace := []slog.Attr{slog.String("trace_id", t.TraceID), slog.String("span_id", t.SpanID)}
+	if len(h.ops) == 0 {
+		r = r.Clone()
+		r.AddAttrs(trace...)
+		return h.Handler.Handle(ctx, r)
+	}
+
+	// The record attrs would be in the open groups so the trace is added before them
+	handler := h.base.WithAttrs(trace)
+	for _, op := range h.ops {
+		if op.group != "" {
+			handler = handler.WithGroup(op.group)
+		} else {
+			handler = handler.WithAttrs(op.attrs)
+		}
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := &traceHandler{Handler: h.Handler.WithAttrs(attrs), base: h.base, ops: h.ops, traced: h.traced}
+	if len(h.ops) > 0 {
+		c.ops = append(h.ops[:len(h.ops):len(h.ops)], traceOp{attrs: attrs})
+		return c
+	}
+	c.base = c.Handler
+	c.traced = c.traced || slices.ContainsFunc(attrs, func(a slog.Attr) bool { return a.Key == "trace_id" })
+	return c
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &traceHandler{
+		Handler: h.Handler.WithGroup(name),
+		base:    h.base,
+		ops:     append(h.ops[:len(h.ops):len(h.ops)], traceOp{group: name}),
+		traced:  h.traced,
+	}
+}
+
+// shortTraceID returns the first 8 characters of a trace id, enough to tell
+// traces apart in a terminal.
+func shortTraceID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroID(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '0' {
+			return false
+		}
+	}
+	return true
+}
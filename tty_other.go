@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package sloghuman
+
+// isTerminalFd always reports false on platforms where terminals can not be
+// detected. Use ColorModeAlways to force color.
+func isTerminalFd(fd uintptr) bool {
+	return false
+}